@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"iulianpascalau/level-db-copy-go/process"
 
@@ -9,6 +12,12 @@ import (
 	"github.com/urfave/cli"
 )
 
+const (
+	verifyListedKeys      = 10
+	defaultSourceDir      = "source"
+	defaultDestinationDir = "destination"
+)
+
 var (
 	logLevel = cli.StringFlag{
 		Name: "log-level",
@@ -22,26 +31,233 @@ var (
 		Name:  "log-save",
 		Usage: "Boolean option for enabling log saving. If set, it will automatically save all the logs into a file.",
 	}
-	sourceDir = cli.StringFlag{
-		Name:  "source",
-		Usage: "The source directory to read data from",
-		Value: "source",
+	sourceDir = cli.StringSliceFlag{
+		Name: "source",
+		Usage: "The source `directory` to read data from. Can be repeated to merge several source directories, in priority " +
+			"order: when the sources hold different values for a key, the first one wins. Default: " + defaultSourceDir,
+	}
+	sourceArchive = cli.StringFlag{
+		Name: "source-archive",
+		Usage: "The archive `file`, written by the export command, used as source before the source directories. " +
+			"If set, the source directory is no longer defaulted",
+	}
+	destinationDir = cli.StringSliceFlag{
+		Name: "destination",
+		Usage: "The destination `directory` to write the missing data to. Can be repeated to fill several destination " +
+			"directories while reading each source DB only once. Default: " + defaultDestinationDir,
+	}
+	recursive = cli.BoolFlag{
+		Name: "recursive",
+		Usage: "Boolean option for searching the level DB directories at any depth under the source and destination " +
+			"directories. The DBs are paired by their path relative to the parent directories",
+	}
+	onConflict = cli.StringFlag{
+		Name: "on-conflict",
+		Usage: fmt.Sprintf("The `policy` applied when a key exists in both the source and the destination DBs "+
+			"but with different values. Available policies: %s", strings.Join(process.ConflictPolicies(), ", ")),
+		Value: process.KeepDestinationPolicy,
+	}
+	dryRun = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Boolean option for only reporting what would be copied. If set, nothing will be written in the destination DBs.",
+	}
+	resume = cli.BoolFlag{
+		Name: "resume",
+		Usage: "Boolean option for resuming an interrupted copy process. If set, the DBs already completed, as recorded " +
+			"in the checkpoint file, are skipped and the current DB is resumed after the last committed key.",
+	}
+	workers = cli.IntFlag{
+		Name:  "workers",
+		Usage: "The `number` of sub-directories processed in parallel",
+		Value: 1,
+	}
+	batchSize = cli.IntFlag{
+		Name:  "batch-size",
+		Usage: "The maximum `number` of entries written in the destination DB in a single atomic batch",
+		Value: 1000,
+	}
+	syncWrites = cli.BoolFlag{
+		Name:  "sync",
+		Usage: "Boolean option for flushing each written batch to the disk (fsync) before continuing",
+	}
+	waitForLock = cli.DurationFlag{
+		Name: "wait-for-lock",
+		Usage: "The maximum `duration` (e.g. 30s, 5m) to wait for the DBs locked by another process (e.g. a running " +
+			"node) to be released. If not set, the process stops right away if a locked DB is found",
+	}
+	createMissing = cli.BoolFlag{
+		Name: "create-missing",
+		Usage: "Boolean option for also copying the sub-directories that exist only in the source directory. " +
+			"If set, the missing destination DBs are created and fully filled with the source data",
+	}
+	mapRules = cli.StringSliceFlag{
+		Name: "map",
+		Usage: "A `source=destination` rule pairing the source and destination sub-directories with different names " +
+			"(e.g. Epoch_1200=Epoch_1201 or */OldUnit=*/NewUnit). The * wildcard matches any text, the destination " +
+			"wildcards being replaced with the matched texts, in order. Can be repeated, the first matching rule wins",
+	}
+	mapFile = cli.StringFlag{
+		Name: "map-file",
+		Usage: "A `file` holding one source=destination mapping rule per line. The empty lines and the ones starting " +
+			"with # are ignored. The rules from the file are applied after the ones provided with --map",
+	}
+	includes = cli.StringSliceFlag{
+		Name: "include",
+		Usage: "A glob `pattern` (e.g. MiniBlocks or Epoch_*/Shard_0/*) selecting the sub-directories to be processed, " +
+			"matched against the relative path or its last element. Can be repeated. If not set, all the sub-directories are included",
+	}
+	excludes = cli.StringSliceFlag{
+		Name: "exclude",
+		Usage: "A glob `pattern` (e.g. PeerAccountsTrie) of the sub-directories that will never be processed, " +
+			"matched against the relative path or its last element. Can be repeated",
+	}
+	startKey = cli.StringFlag{
+		Name: "start-key",
+		Usage: "The first `key` (inclusive) to be processed from each DB, given as UTF-8 text or as hex with the 0x " +
+			"prefix. If not set, the DBs are processed from the first key",
+	}
+	endKey = cli.StringFlag{
+		Name: "end-key",
+		Usage: "The `key` (exclusive) where the processing of each DB stops, given as UTF-8 text or as hex with the 0x " +
+			"prefix. If not set, the DBs are processed up to the last key",
+	}
+	keyPrefix = cli.StringFlag{
+		Name: "key-prefix",
+		Usage: "Only the keys starting with this `prefix`, given as UTF-8 text or as hex with the 0x prefix, are " +
+			"processed. Can be combined with --start-key and --end-key",
+	}
+	outputFormat = cli.StringFlag{
+		Name:  "output",
+		Usage: fmt.Sprintf("The report `format`. Available formats: %s", strings.Join(process.OutputFormats(), ", ")),
+		Value: process.TextOutputFormat,
+	}
+	listKeys = cli.IntFlag{
+		Name:  "list-keys",
+		Usage: "The maximum `number` of hex encoded keys listed for each DB and each difference category. If 0, only the counts are reported",
+	}
+	reportFile = cli.StringFlag{
+		Name:  "report-file",
+		Usage: "The `file` where the report is written. If not set, the report is written to the standard output, after the logs",
+	}
+	copyStrategy = cli.StringFlag{
+		Name: "strategy",
+		Usage: fmt.Sprintf("The `strategy` used to find the missing keys. The %s strategy walks the source and "+
+			"destination DBs side by side with sequential reads, the %s strategy looks up each source key in the destination. "+
+			"Available strategies: %s", process.MergeJoinCopyStrategy, process.GetCopyStrategy, strings.Join(process.CopyStrategies(), ", ")),
+		Value: process.MergeJoinCopyStrategy,
+	}
+	bidirectional = cli.BoolFlag{
+		Name: "bidirectional",
+		Usage: "Boolean option for also copying the keys missing from the source DBs, found in the destination DBs, in a " +
+			"single pass per DB pair. The source DBs are opened in write mode. Requires the " + process.MergeJoinCopyStrategy + " strategy",
+	}
+	mirror = cli.BoolFlag{
+		Name: "mirror",
+		Usage: "Boolean option for removing the destination keys missing from the source, so the destination matches " +
+			"the source. Requires the " + process.MergeJoinCopyStrategy + " strategy and the --confirm-mirror option",
+	}
+	confirmMirror = cli.BoolFlag{
+		Name:  "confirm-mirror",
+		Usage: "Boolean option confirming that the mirror mode can remove data from the destination DBs. Not required in dry run mode",
+	}
+	maxDeletions = cli.IntFlag{
+		Name:  "max-deletions",
+		Usage: "The maximum `number` of keys the mirror mode can remove from a DB. If exceeded, the DB is left untouched. 0 means no limit",
+		Value: 1000,
+	}
+	maxDeletionsPercent = cli.Float64Flag{
+		Name: "max-deletions-percent",
+		Usage: "The maximum `percentage` of the destination keys the mirror mode can remove from a DB. If exceeded, the DB " +
+			"is left untouched. 0 means no limit",
+		Value: 10,
+	}
+	verifyAfterCopy = cli.BoolFlag{
+		Name: "verify",
+		Usage: "Boolean option for verifying, after the copy, that every source key is present in the destination. " +
+			"The process exits with an error and a per-DB report if keys are missing",
+	}
+	verifyValues = cli.BoolFlag{
+		Name:  "verify-values",
+		Usage: "Boolean option for also checking, when verifying, that the source and destination values are equal",
 	}
-	destinationDir = cli.StringFlag{
-		Name:  "destination",
-		Usage: "The destination directory to write the missing data to",
-		Value: "destination",
+	archiveFile = cli.StringFlag{
+		Name:  "archive-file",
+		Usage: "The archive `file` the selected source DBs are exported to",
+	}
+	missingOnly = cli.BoolFlag{
+		Name: "missing-only",
+		Usage: "Boolean option for exporting only the keys missing from the destination directory, the DBs missing " +
+			"from the destination directory being fully exported",
+	}
+	dumpDB = cli.StringFlag{
+		Name:  "db",
+		Usage: "The DB `directory` to be dumped. If not set, the source DBs selected by the global options are dumped",
+	}
+	recordFormat = cli.StringFlag{
+		Name:  "format",
+		Usage: fmt.Sprintf("The records `format`. Available formats: %s", strings.Join(process.RecordFormats(), ", ")),
+		Value: process.JSONLRecordFormat,
+	}
+	keyEncoding = cli.StringFlag{
+		Name:  "key-encoding",
+		Usage: fmt.Sprintf("The keys `encoding`. Available encodings: %s", strings.Join(process.Encodings(), ", ")),
+		Value: process.AutoEncoding,
+	}
+	valueEncoding = cli.StringFlag{
+		Name:  "value-encoding",
+		Usage: fmt.Sprintf("The values `encoding`. Available encodings: %s", strings.Join(process.Encodings(), ", ")),
+		Value: process.AutoEncoding,
+	}
+	limit = cli.IntFlag{
+		Name:  "limit",
+		Usage: "The maximum `number` of records written for each DB. If 0, all the records are written",
+	}
+	keysOnly = cli.BoolFlag{
+		Name:  "keys-only",
+		Usage: "Boolean option for writing only the keys, without the values",
+	}
+	outputFile = cli.StringFlag{
+		Name: "output-file",
+		Usage: "The `file` where the records are written. If not set, the records are written to the standard output " +
+			"and the logs to the standard error",
+	}
+	loadDB = cli.StringFlag{
+		Name: "db",
+		Usage: "The DB `directory` the records are loaded in. If not set, each record is loaded in the DB it names, " +
+			"under the destination directory",
+	}
+	loadKeyEncoding = cli.StringFlag{
+		Name:  "key-encoding",
+		Usage: "The keys `encoding` for the records that do not hold it. Available encodings: hex, base64, utf8",
+		Value: process.UTF8Encoding,
+	}
+	loadValueEncoding = cli.StringFlag{
+		Name:  "value-encoding",
+		Usage: "The values `encoding` for the records that do not hold it. Available encodings: hex, base64, utf8",
+		Value: process.UTF8Encoding,
+	}
+	inputFile = cli.StringFlag{
+		Name:  "input-file",
+		Usage: "The `file` the records are read from. If not set, the records are read from the standard input",
+	}
+	checkpointFile = cli.StringFlag{
+		Name: "checkpoint-file",
+		Usage: "The checkpoint `file` used to record the copy progress. If not set, the file " + process.CheckpointFileName +
+			" from the first destination directory will be used",
 	}
 
 	log          = logger.GetOrCreate("tool")
 	helpTemplate = `NAME:
    {{.Name}} - {{.Usage}}
 USAGE:
-   {{.HelpName}} {{if .VisibleFlags}}[global options]{{end}}
+   {{.HelpName}} {{if .VisibleFlags}}[global options]{{end}}{{if .Commands}} [command [command options]]{{end}}
    {{if len .Authors}}
 AUTHOR:
    {{range .Authors}}{{ . }}{{end}}
    {{end}}{{if .Commands}}
+COMMANDS:
+   {{range .VisibleCommands}}{{join .Names ", "}}{{"\t"}}{{.Usage}}
+   {{end}}
 GLOBAL OPTIONS:
    {{range .VisibleFlags}}{{.}}
    {{end}}
@@ -60,7 +276,33 @@ func main() {
 		logLevel,
 		logSaveFile,
 		sourceDir,
+		sourceArchive,
 		destinationDir,
+		recursive,
+		onConflict,
+		dryRun,
+		resume,
+		checkpointFile,
+		workers,
+		batchSize,
+		syncWrites,
+		waitForLock,
+		createMissing,
+		mapRules,
+		mapFile,
+		includes,
+		excludes,
+		startKey,
+		endKey,
+		keyPrefix,
+		verifyAfterCopy,
+		verifyValues,
+		copyStrategy,
+		bidirectional,
+		mirror,
+		confirmMirror,
+		maxDeletions,
+		maxDeletionsPercent,
 	}
 
 	app.Authors = []cli.Author{
@@ -71,6 +313,40 @@ func main() {
 	}
 
 	app.Action = copyProcess
+	app.Commands = []cli.Command{
+		{
+			Name:   "diff",
+			Usage:  "compares the source and destination DBs selected by the global options, without changing them",
+			Flags:  []cli.Flag{outputFormat, listKeys, reportFile},
+			Action: diffProcess,
+		},
+		{
+			Name:   "verify",
+			Usage:  "checks that every source key is present in the destination, exiting with an error if keys are missing",
+			Flags:  []cli.Flag{outputFormat, listKeys, reportFile},
+			Action: verifyProcess,
+		},
+		{
+			Name: "export",
+			Usage: "writes the source DBs selected by the global options, or only the keys missing from the destination, " +
+				"in a single archive file",
+			Flags:  []cli.Flag{archiveFile, missingOnly},
+			Action: exportProcess,
+		},
+		{
+			Name:   "dump",
+			Usage:  "writes the keys and values of a DB, or of the source DBs selected by the global options, as records",
+			Flags:  []cli.Flag{dumpDB, recordFormat, keyEncoding, valueEncoding, limit, keysOnly, outputFile},
+			Action: dumpProcess,
+		},
+		{
+			Name: "load",
+			Usage: "writes the records produced by the dump command, or hand-crafted, in a DB or in the destination DBs, " +
+				"with the same conflict handling as the copy",
+			Flags:  []cli.Flag{loadDB, recordFormat, loadKeyEncoding, loadValueEncoding, inputFile},
+			Action: loadProcess,
+		},
+	}
 
 	err := app.Run(os.Args)
 	if err != nil {
@@ -81,25 +357,490 @@ func main() {
 
 func copyProcess(ctx *cli.Context) error {
 	log.Info("Level DB copy missing data tool. Copying data",
-		"from", ctx.GlobalString(sourceDir.Name),
-		"to", ctx.GlobalString(destinationDir.Name))
+		"from", strings.Join(getSourceDirs(ctx), ", "),
+		"source archive", ctx.GlobalString(sourceArchive.Name),
+		"to", strings.Join(getDestinationDirs(ctx), ", "),
+		"dry run", ctx.GlobalBool(dryRun.Name),
+		"bidirectional", ctx.GlobalBool(bidirectional.Name),
+		"mirror", ctx.GlobalBool(mirror.Name))
+
+	dirHandler, factory, err := createDirectoriesHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	conflictResolver, err := process.NewConflictResolver(ctx.GlobalString(onConflict.Name))
+	if err != nil {
+		return err
+	}
+
+	checkpointHandler, err := createCheckpointHandler(ctx)
+	if err != nil {
+		return err
+	}
+
+	keyRange, err := createKeyRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbCopyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler:  dirHandler,
+		DBWrapperFactory:    factory,
+		NumWorkers:          ctx.GlobalInt(workers.Name),
+		ConflictResolver:    conflictResolver,
+		CheckpointHandler:   checkpointHandler,
+		LockChecker:         process.NewLockChecker(),
+		WaitForLock:         ctx.GlobalDuration(waitForLock.Name),
+		BatchSize:           ctx.GlobalInt(batchSize.Name),
+		SyncWrites:          ctx.GlobalBool(syncWrites.Name),
+		DryRun:              ctx.GlobalBool(dryRun.Name),
+		CreateMissing:       ctx.GlobalBool(createMissing.Name),
+		KeyRange:            keyRange,
+		CopyStrategy:        ctx.GlobalString(copyStrategy.Name),
+		Bidirectional:       ctx.GlobalBool(bidirectional.Name),
+		Mirror:              ctx.GlobalBool(mirror.Name),
+		MirrorConfirmed:     ctx.GlobalBool(confirmMirror.Name),
+		MaxDeletions:        ctx.GlobalInt(maxDeletions.Name),
+		MaxDeletionsPercent: ctx.GlobalFloat64(maxDeletionsPercent.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = dbCopyHandler.Process()
+	if err != nil {
+		return err
+	}
+	if !ctx.GlobalBool(verifyAfterCopy.Name) {
+		return nil
+	}
+	if ctx.GlobalBool(dryRun.Name) {
+		log.Warn("verification skipped in dry run mode")
+		return nil
+	}
+
+	return runVerification(ctx, process.TextOutputFormat, verifyListedKeys, "")
+}
+
+func diffProcess(ctx *cli.Context) error {
+	log.Info("Level DB copy missing data tool. Comparing data",
+		"source", strings.Join(getSourceDirs(ctx), ", "),
+		"destination", strings.Join(getDestinationDirs(ctx), ", "))
+
+	format := ctx.String(outputFormat.Name)
+	err := process.CheckOutputFormat(format)
+	if err != nil {
+		return err
+	}
+
+	report, err := createDiffReport(ctx, ctx.Int(listKeys.Name))
+	if err != nil {
+		return err
+	}
+
+	return writeReport(ctx.String(reportFile.Name), func(writer io.Writer) error {
+		return process.WriteDiffReport(writer, report, format)
+	})
+}
+
+func verifyProcess(ctx *cli.Context) error {
+	log.Info("Level DB copy missing data tool. Verifying data",
+		"source", strings.Join(getSourceDirs(ctx), ", "),
+		"destination", strings.Join(getDestinationDirs(ctx), ", "),
+		"verify values", ctx.GlobalBool(verifyValues.Name))
+
+	format := ctx.String(outputFormat.Name)
+	err := process.CheckOutputFormat(format)
+	if err != nil {
+		return err
+	}
+
+	return runVerification(ctx, format, ctx.Int(listKeys.Name), ctx.String(reportFile.Name))
+}
+
+// runVerification re-reads all the paired DBs and returns an error if source keys are missing from the destination,
+// after writing the per-DB report
+func runVerification(ctx *cli.Context, format string, maxListedKeys int, reportFilePath string) error {
+	report, err := createDiffReport(ctx, maxListedKeys)
+	if err != nil {
+		return err
+	}
+
+	errVerify := report.Verify(ctx.GlobalBool(verifyValues.Name))
+	err = writeReport(reportFilePath, func(writer io.Writer) error {
+		return process.WriteDiffReport(writer, report, format)
+	})
+	if err != nil {
+		return err
+	}
+	if errVerify != nil {
+		return errVerify
+	}
+
+	log.Info("verification passed", "verified DBs", len(report.DBs))
+
+	return nil
+}
+
+func createDiffReport(ctx *cli.Context, maxListedKeys int) (*process.DiffReport, error) {
+	dirHandler, factory, err := createDirectoriesHandler(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRange, err := createKeyRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diffHandler, err := process.NewDiffHandler(process.ArgsDiffHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   factory,
+		KeyRange:           keyRange,
+		MaxListedKeys:      maxListedKeys,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return diffHandler.Process()
+}
+
+func writeReport(filePath string, write func(writer io.Writer) error) error {
+	if len(filePath) == 0 {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	log.Info("report written", "file", filePath)
+
+	return file.Close()
+}
+
+func exportProcess(ctx *cli.Context) error {
+	filePath := ctx.String(archiveFile.Name)
+	if len(filePath) == 0 {
+		return fmt.Errorf("the --%s option is mandatory", archiveFile.Name)
+	}
+
+	isMissingOnly := ctx.Bool(missingOnly.Name)
+	log.Info("Level DB copy missing data tool. Exporting data",
+		"source", strings.Join(getSourceDirs(ctx), ", "),
+		"archive", filePath,
+		"missing only", isMissingOnly)
+
+	destinationDirs := make([]string, 0)
+	if isMissingOnly {
+		destinationDirs = getDestinationDirs(ctx)
+		log.Info("exporting only the keys missing from", "destination", strings.Join(destinationDirs, ", "))
+	}
+
+	dirHandler, factory, err := createDirectoriesHandlerWithDestinations(ctx, destinationDirs)
+	if err != nil {
+		return err
+	}
+
+	keyRange, err := createKeyRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	exportHandler, err := process.NewExportHandler(process.ArgsExportHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   factory,
+		KeyRange:           keyRange,
+		MissingOnly:        isMissingOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeArchive(filePath, exportHandler.Process)
+}
+
+// writeArchive writes the archive in a temporary file, renamed only once completely written, so an interrupted
+// export never leaves a partial archive behind
+func writeArchive(filePath string, write func(writer io.Writer) error) error {
+	tempFilePath := filePath + ".tmp"
+	file, err := os.Create(tempFilePath)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	errClose := file.Close()
+	if err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(tempFilePath)
+		return err
+	}
+
+	err = os.Rename(tempFilePath, filePath)
+	if err != nil {
+		return err
+	}
+
+	log.Info("archive written", "file", filePath)
+
+	return nil
+}
+
+func dumpProcess(ctx *cli.Context) error {
+	filePath := ctx.String(outputFile.Name)
+	if len(filePath) == 0 {
+		// the records are written to the standard output, so the logs are moved to the standard error
+		err := redirectLogsToStderr()
+		if err != nil {
+			return err
+		}
+	}
+
+	dbPath := ctx.String(dumpDB.Name)
+	log.Info("Level DB copy missing data tool. Dumping data",
+		"db", dbPath,
+		"format", ctx.String(recordFormat.Name),
+		"key encoding", ctx.String(keyEncoding.Name),
+		"value encoding", ctx.String(valueEncoding.Name))
+
+	var dirHandler process.DirectoriesHandler
+	var factory process.DBWrapperFactory = process.NewDBWrapperFactory()
+	if len(dbPath) == 0 {
+		var err error
+		dirHandler, factory, err = createDirectoriesHandlerWithDestinations(ctx, make([]string, 0))
+		if err != nil {
+			return err
+		}
+	}
+
+	keyRange, err := createKeyRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	dumpHandler, err := process.NewDumpHandler(process.ArgsDumpHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   factory,
+		DBPath:             dbPath,
+		KeyRange:           keyRange,
+		RecordFormat:       ctx.String(recordFormat.Name),
+		KeyEncoding:        ctx.String(keyEncoding.Name),
+		ValueEncoding:      ctx.String(valueEncoding.Name),
+		Limit:              ctx.Int(limit.Name),
+		KeysOnly:           ctx.Bool(keysOnly.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeReport(filePath, dumpHandler.Process)
+}
+
+func loadProcess(ctx *cli.Context) error {
+	dbPath := ctx.String(loadDB.Name)
+	destParentDir := ""
+	if len(dbPath) == 0 {
+		destinationDirs := getDestinationDirs(ctx)
+		if len(destinationDirs) != 1 {
+			return fmt.Errorf("the load command supports a single --%s directory", destinationDir.Name)
+		}
+		destParentDir = destinationDirs[0]
+	}
+
+	filePath := ctx.String(inputFile.Name)
+	log.Info("Level DB copy missing data tool. Loading data",
+		"from", filePath,
+		"db", dbPath,
+		"destination", destParentDir,
+		"format", ctx.String(recordFormat.Name),
+		"dry run", ctx.GlobalBool(dryRun.Name))
+
+	conflictResolver, err := process.NewConflictResolver(ctx.GlobalString(onConflict.Name))
+	if err != nil {
+		return err
+	}
 
-	dirHandler, err := process.NewDirectoriesHandler(
-		ctx.GlobalString(sourceDir.Name),
-		ctx.GlobalString(destinationDir.Name),
-	)
+	loadHandler, err := process.NewLoadHandler(process.ArgsLoadHandler{
+		DBWrapperFactory: process.NewDBWrapperFactory(),
+		ConflictResolver: conflictResolver,
+		DBPath:           dbPath,
+		DestParentDir:    destParentDir,
+		RecordFormat:     ctx.String(recordFormat.Name),
+		KeyEncoding:      ctx.String(loadKeyEncoding.Name),
+		ValueEncoding:    ctx.String(loadValueEncoding.Name),
+		CreateMissing:    ctx.GlobalBool(createMissing.Name),
+		DryRun:           ctx.GlobalBool(dryRun.Name),
+	})
 	if err != nil {
 		return err
 	}
 
-	dbCopyHandler, err := process.NewDataCopyHandler(
-		dirHandler,
-		process.NewDBWrapper(),
-		process.NewDBWrapper(),
-	)
+	if len(filePath) == 0 {
+		return loadHandler.Process(os.Stdin)
+	}
+
+	file, err := os.Open(filePath)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return loadHandler.Process(file)
+}
+
+func redirectLogsToStderr() error {
+	err := logger.RemoveLogObserver(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	return logger.AddLogObserver(os.Stderr, &logger.ConsoleFormatter{})
+}
+
+func createDirectoriesHandler(ctx *cli.Context) (process.DirectoriesHandler, process.DBWrapperFactory, error) {
+	return createDirectoriesHandlerWithDestinations(ctx, getDestinationDirs(ctx))
+}
+
+// createDirectoriesHandlerWithDestinations creates the directories handler together with the DB wrapper factory to be
+// used for the selected DBs. When a source archive is provided, the factory also creates the DB wrappers backed by the
+// archive sections
+func createDirectoriesHandlerWithDestinations(
+	ctx *cli.Context,
+	destinationDirs []string,
+) (process.DirectoriesHandler, process.DBWrapperFactory, error) {
+	mappingRules, err := getMappingRules(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var archive process.SourceArchive
+	var factory process.DBWrapperFactory = process.NewDBWrapperFactory()
+	archiveFilePath := ctx.GlobalString(sourceArchive.Name)
+	if len(archiveFilePath) > 0 {
+		archiveSource, errArchive := process.NewArchiveSource(archiveFilePath, factory)
+		if errArchive != nil {
+			return nil, nil, errArchive
+		}
+
+		archive = archiveSource
+		factory = archiveSource
+	}
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceArchive:    archive,
+		SourceParentDirs: getSourceDirs(ctx),
+		DestParentDirs:   destinationDirs,
+		Recursive:        ctx.GlobalBool(recursive.Name),
+		MappingRules:     mappingRules,
+		Includes:         ctx.GlobalStringSlice(includes.Name),
+		Excludes:         ctx.GlobalStringSlice(excludes.Name),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dirHandler, factory, nil
+}
+
+func getSourceDirs(ctx *cli.Context) []string {
+	sourceDirs := ctx.GlobalStringSlice(sourceDir.Name)
+	if len(sourceDirs) == 0 && len(ctx.GlobalString(sourceArchive.Name)) == 0 {
+		return []string{defaultSourceDir}
+	}
+
+	return sourceDirs
+}
+
+func getDestinationDirs(ctx *cli.Context) []string {
+	destinationDirs := ctx.GlobalStringSlice(destinationDir.Name)
+	if len(destinationDirs) == 0 {
+		return []string{defaultDestinationDir}
+	}
+
+	return destinationDirs
+}
+
+func createKeyRange(ctx *cli.Context) (process.KeyRange, error) {
+	return process.NewKeyRange(ctx.GlobalString(startKey.Name), ctx.GlobalString(endKey.Name), ctx.GlobalString(keyPrefix.Name))
+}
+
+func getMappingRules(ctx *cli.Context) ([]string, error) {
+	rules := ctx.GlobalStringSlice(mapRules.Name)
+	filePath := ctx.GlobalString(mapFile.Name)
+	if len(filePath) == 0 {
+		return rules, nil
+	}
+
+	rulesFromFile, err := process.ReadMappingFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rules, rulesFromFile...), nil
+}
+
+func createCheckpointHandler(ctx *cli.Context) (process.CheckpointHandler, error) {
+	if ctx.GlobalBool(dryRun.Name) {
+		return process.NewDisabledCheckpointHandler(), nil
+	}
+
+	filePath := ctx.GlobalString(checkpointFile.Name)
+	if len(filePath) == 0 {
+		filePath = process.DefaultCheckpointFilePath(getDestinationDirs(ctx)[0])
+	}
+
+	runInfo, err := getCheckpointRunInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return process.NewCheckpointHandler(process.ArgsCheckpointHandler{
+		FilePath: filePath,
+		Resume:   ctx.GlobalBool(resume.Name),
+		RunInfo:  runInfo,
+	})
+}
+
+// getCheckpointRunInfo returns the paths and the options changing the copied data, recorded in the checkpoint file
+// so that a resumed run is the same run as the interrupted one
+func getCheckpointRunInfo(ctx *cli.Context) (map[string]string, error) {
+	mappingRules, err := getMappingRules(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	return dbCopyHandler.Process()
+	return map[string]string{
+		sourceDir.Name:      strings.Join(getSourceDirs(ctx), ", "),
+		sourceArchive.Name:  ctx.GlobalString(sourceArchive.Name),
+		destinationDir.Name: strings.Join(getDestinationDirs(ctx), ", "),
+		recursive.Name:      fmt.Sprint(ctx.GlobalBool(recursive.Name)),
+		onConflict.Name:     ctx.GlobalString(onConflict.Name),
+		createMissing.Name:  fmt.Sprint(ctx.GlobalBool(createMissing.Name)),
+		mapRules.Name:       strings.Join(mappingRules, ", "),
+		includes.Name:       strings.Join(ctx.GlobalStringSlice(includes.Name), ", "),
+		excludes.Name:       strings.Join(ctx.GlobalStringSlice(excludes.Name), ", "),
+		startKey.Name:       ctx.GlobalString(startKey.Name),
+		endKey.Name:         ctx.GlobalString(endKey.Name),
+		keyPrefix.Name:      ctx.GlobalString(keyPrefix.Name),
+		copyStrategy.Name:   ctx.GlobalString(copyStrategy.Name),
+		bidirectional.Name:  fmt.Sprint(ctx.GlobalBool(bidirectional.Name)),
+		mirror.Name:         fmt.Sprint(ctx.GlobalBool(mirror.Name)),
+	}, nil
 }
@@ -0,0 +1,12 @@
+package common
+
+// DBIterator defines an ordered iterator over the keys of a DB. The iterator is positioned before the first key
+// when created, so Next should be called before reading the first key. It must be released after use
+type DBIterator interface {
+	Seek(key []byte) bool
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotInfo identifies the point-in-time state of a DB that is read
+type SnapshotInfo struct {
+	Sequence uint64    `json:"sequence"`
+	Time     time.Time `json:"time"`
+}
+
+// String returns the snapshot sequence and time as text
+func (info *SnapshotInfo) String() string {
+	if info == nil {
+		return "none"
+	}
+
+	return fmt.Sprintf("sequence %d at %s", info.Sequence, info.Time.UTC().Format(time.RFC3339Nano))
+}
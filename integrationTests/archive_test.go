@@ -0,0 +1,129 @@
+package integrationTests
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/process"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBCopyFromArchive(t *testing.T) {
+	for _, strategy := range process.CopyStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			testDBCopyFromArchive(t, strategy)
+		})
+	}
+}
+
+func testDBCopyFromArchive(t *testing.T, strategy string) {
+	srcParentDir, destParentDir := setupDirs(t)
+	archiveFilePath := exportArchive(t, srcParentDir, destParentDir)
+
+	archiveSource, err := process.NewArchiveSource(archiveFilePath, process.NewDBWrapperFactory())
+	require.Nil(t, err)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceArchive:  archiveSource,
+		DestParentDirs: []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	runCopy(t, dirHandler, archiveSource, strategy)
+
+	// the copy from the archive should end up with the same data as the direct copy
+	_, expectedDestParentDir := setupDirs(t)
+	dirHandler, err = process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{expectedDestParentDir},
+	})
+	require.Nil(t, err)
+
+	runCopy(t, dirHandler, process.NewDBWrapperFactory(), strategy)
+
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		assert.Equal(t, getAllData(t, path.Join(expectedDestParentDir, name)), getAllData(t, path.Join(destParentDir, name)), name)
+	}
+}
+
+func TestDBCopyFromArchiveBidirectionalShouldError(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+	archiveFilePath := exportArchive(t, srcParentDir, destParentDir)
+
+	archiveSource, err := process.NewArchiveSource(archiveFilePath, process.NewDBWrapperFactory())
+	require.Nil(t, err)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceArchive:  archiveSource,
+		DestParentDirs: []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	require.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   archiveSource,
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		Bidirectional:      true,
+	})
+	require.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.NotNil(t, err)
+}
+
+func exportArchive(t *testing.T, srcParentDir string, destParentDir string) string {
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	exportHandler, err := process.NewExportHandler(process.ArgsExportHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		MissingOnly:        true,
+	})
+	require.Nil(t, err)
+
+	archiveFilePath := filepath.Join(t.TempDir(), "missing.ldbarch")
+	file, err := os.Create(archiveFilePath)
+	require.Nil(t, err)
+	err = exportHandler.Process(file)
+	require.Nil(t, err)
+	require.Nil(t, file.Close())
+
+	return archiveFilePath
+}
+
+func runCopy(t *testing.T, dirHandler process.DirectoriesHandler, factory process.DBWrapperFactory, strategy string) {
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	require.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   factory,
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       strategy,
+		CreateMissing:      true,
+	})
+	require.Nil(t, err)
+
+	err = copyHandler.Process()
+	require.Nil(t, err)
+}
@@ -0,0 +1,101 @@
+package integrationTests
+
+import (
+	"crypto/rand"
+	"fmt"
+	"path"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/process"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	benchmarkKeySize        = 32
+	benchmarkValueSize      = 100
+	benchmarkBatchSize      = 1000
+	benchmarkMissingKeyStep = 10
+)
+
+// BenchmarkCopyStrategies compares the Get-based and the merge-join strategies on generated DBs holding random
+// 32 bytes keys, the destination missing every 10th source key. The dry-run mode is used so each iteration
+// analyses the same data
+func BenchmarkCopyStrategies(b *testing.B) {
+	for _, numKeys := range []int{10000, 100000} {
+		srcParentDir, destParentDir := generateBenchmarkDirs(b, numKeys)
+
+		for _, strategy := range process.CopyStrategies() {
+			b.Run(fmt.Sprintf("%s/%d keys", strategy, numKeys), func(b *testing.B) {
+				dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+					SourceParentDirs: []string{srcParentDir},
+					DestParentDirs:   []string{destParentDir},
+				})
+				require.Nil(b, err)
+
+				conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+				require.Nil(b, err)
+
+				copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+					DirectoriesHandler: dirHandler,
+					DBWrapperFactory:   process.NewDBWrapperFactory(),
+					NumWorkers:         1,
+					ConflictResolver:   conflictResolver,
+					CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+					LockChecker:        process.NewLockChecker(),
+					BatchSize:          benchmarkBatchSize,
+					DryRun:             true,
+					CopyStrategy:       strategy,
+				})
+				require.Nil(b, err)
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err = copyHandler.Process()
+					require.Nil(b, err)
+				}
+			})
+		}
+	}
+}
+
+func generateBenchmarkDirs(b *testing.B, numKeys int) (string, string) {
+	srcParentDir := b.TempDir()
+	destParentDir := b.TempDir()
+
+	src := process.NewDBWrapper()
+	require.Nil(b, src.Open(path.Join(srcParentDir, "DB")))
+	dest := process.NewDBWrapper()
+	require.Nil(b, dest.Open(path.Join(destParentDir, "DB")))
+
+	srcKeys, srcValues := make([][]byte, 0, benchmarkBatchSize), make([][]byte, 0, benchmarkBatchSize)
+	destKeys, destValues := make([][]byte, 0, benchmarkBatchSize), make([][]byte, 0, benchmarkBatchSize)
+	for i := 0; i < numKeys; i++ {
+		key := generateRandomBytes(b, benchmarkKeySize)
+		value := generateRandomBytes(b, benchmarkValueSize)
+		srcKeys, srcValues = append(srcKeys, key), append(srcValues, value)
+		if i%benchmarkMissingKeyStep != 0 {
+			destKeys, destValues = append(destKeys, key), append(destValues, value)
+		}
+
+		if len(srcKeys) == benchmarkBatchSize || i == numKeys-1 {
+			require.Nil(b, src.WriteBatch(srcKeys, srcValues, nil, false))
+			require.Nil(b, dest.WriteBatch(destKeys, destValues, nil, false))
+			srcKeys, srcValues = srcKeys[:0], srcValues[:0]
+			destKeys, destValues = destKeys[:0], destValues[:0]
+		}
+	}
+
+	require.Nil(b, src.Close())
+	require.Nil(b, dest.Close())
+
+	return srcParentDir, destParentDir
+}
+
+func generateRandomBytes(b *testing.B, size int) []byte {
+	buff := make([]byte, size)
+	_, err := rand.Read(buff)
+	require.Nil(b, err)
+
+	return buff
+}
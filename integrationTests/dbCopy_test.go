@@ -1,8 +1,11 @@
 package integrationTests
 
 import (
+	"encoding/hex"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"iulianpascalau/level-db-copy-go/process"
@@ -12,16 +15,36 @@ import (
 )
 
 func TestDBCopy(t *testing.T) {
+	for _, strategy := range process.CopyStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			testDBCopy(t, strategy)
+		})
+	}
+}
+
+func testDBCopy(t *testing.T, strategy string) {
 	srcParentDir, destParentDir := setupDirs(t)
+	srcContentBefore := getFilesContent(t, srcParentDir)
 
-	dirHandler, err := process.NewDirectoriesHandler(srcParentDir, destParentDir)
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
 	assert.Nil(t, err)
 
-	copyHandler, err := process.NewDataCopyHandler(
-		dirHandler,
-		process.NewDBWrapper(),
-		process.NewDBWrapper(),
-	)
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       strategy,
+	})
 	assert.Nil(t, err)
 
 	err = copyHandler.Process()
@@ -55,6 +78,645 @@ func TestDBCopy(t *testing.T) {
 	assert.Equal(t, expectedDdata, getAllData(t, path.Join(destParentDir, "D")))
 	assert.Equal(t, expectedEdata, getAllData(t, path.Join(destParentDir, "E")))
 	assert.Equal(t, expectedFdata, getAllData(t, path.Join(destParentDir, "F")))
+
+	// the source DBs are opened in read-only mode, no file should have been changed
+	assert.Equal(t, srcContentBefore, getFilesContent(t, srcParentDir))
+}
+
+func TestDBCopyWithCreateMissing(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		CreateMissing:      true,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key3": "B-value-s-3", // copied from src
+		"B-key4": "B-value-d-4",
+	}
+	expectedEdata := map[string]string{
+		"E-key1": "E-value-s-1", // created from src
+	}
+	expectedFdata := map[string]string{
+		"F-key1": "F-value-d-1",
+	}
+
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+	assert.Equal(t, expectedEdata, getAllData(t, path.Join(destParentDir, "E")))
+	assert.Equal(t, expectedFdata, getAllData(t, path.Join(destParentDir, "F")))
+}
+
+func TestDBCopyShouldSkipTheSourceDirectoriesThatAreNotDBs(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+	// a common sub-directory that is not a level DB, sorted between the DBs B and C
+	for _, parentDir := range []string{srcParentDir, destParentDir} {
+		err := os.MkdirAll(path.Join(parentDir, "B_notADB"), 0700)
+		require.Nil(t, err)
+		err = os.WriteFile(path.Join(parentDir, "B_notADB", "notes.txt"), []byte("not a DB"), 0600)
+		require.Nil(t, err)
+	}
+	notADBContentBefore := getFilesContent(t, path.Join(destParentDir, "B_notADB"))
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	require.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		CreateMissing:      true,
+	})
+	require.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedEdata := map[string]string{
+		"E-key1": "E-value-s-1", // created from src, after the skipped directory
+	}
+	assert.Equal(t, "B-value-s-3", getAllData(t, path.Join(destParentDir, "B"))["B-key3"])
+	assert.Equal(t, expectedEdata, getAllData(t, path.Join(destParentDir, "E")))
+	assert.Equal(t, notADBContentBefore, getFilesContent(t, path.Join(destParentDir, "B_notADB")))
+}
+
+func TestDBCopyWithMappingRules(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+		MappingRules:     []string{"E=F"},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedFdata := map[string]string{
+		"E-key1": "E-value-s-1", // copied from src E
+		"F-key1": "F-value-d-1",
+	}
+	var expectedEdata map[string]string = nil
+
+	assert.Equal(t, expectedFdata, getAllData(t, path.Join(destParentDir, "F")))
+	assert.Equal(t, expectedEdata, getAllData(t, path.Join(destParentDir, "E")))
+}
+
+func TestDBCopyWithFilters(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+		Excludes:         []string{"B"},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	// B-key3 is missing from the destination but B was excluded
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key4": "B-value-d-4",
+	}
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+}
+
+func TestDBCopyWithKeyPrefix(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+	putData(t,
+		path.Join(srcParentDir, "B"),
+		[]string{"B-other-key1"},
+		[]string{"B-other-value-s-1"},
+	)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	keyRange, err := process.NewKeyRange("", "", "0x"+hex.EncodeToString([]byte("B-key")))
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		KeyRange:           keyRange,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key3": "B-value-s-3", // copied from src
+		"B-key4": "B-value-d-4",
+	}
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+}
+
+func TestDBCopyWithResume(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+	checkpointArgs := process.ArgsCheckpointHandler{
+		FilePath: process.DefaultCheckpointFilePath(destParentDir),
+		RunInfo: map[string]string{
+			"source":      srcParentDir,
+			"destination": destParentDir,
+		},
+	}
+
+	// simulate an interrupted run that already completed the DB B
+	checkpointHandler, err := process.NewCheckpointHandler(checkpointArgs)
+	require.Nil(t, err)
+	require.Nil(t, checkpointHandler.MarkCompleted("B"))
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	checkpointArgs.Resume = true
+	checkpointHandler, err = process.NewCheckpointHandler(checkpointArgs)
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  checkpointHandler,
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		SyncWrites:         true,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key4": "B-value-d-4",
+	}
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+
+	// all the DBs were processed so the next run must start from scratch
+	assert.NoFileExists(t, checkpointArgs.FilePath)
+}
+
+func TestDBCopyWithMultipleWorkers(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.OverwriteWithSourcePolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         3,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedAdata := map[string]string{
+		"A-key1": "A-value-s-1",
+		"A-key2": "A-value-s-2",
+		"A-key3": "A-value-s-3",
+	}
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-s-1",
+		"B-key2": "B-value-s-2",
+		"B-key3": "B-value-s-3",
+		"B-key4": "B-value-s-4",
+	}
+	expectedCdata := map[string]string{
+		"C-key1": "C-value-s-1",
+		"C-key2": "C-value-s-2",
+		"C-key3": "C-value-d-3",
+	}
+
+	assert.Equal(t, expectedAdata, getAllData(t, path.Join(destParentDir, "A")))
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+	assert.Equal(t, expectedCdata, getAllData(t, path.Join(destParentDir, "C")))
+	assert.Equal(t, make(map[string]string), getAllData(t, path.Join(destParentDir, "D")))
+}
+
+func TestDBCopyWithLockedDB(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	// simulate a running node that holds the destination DB C
+	lockedDB := process.NewDBWrapper()
+	require.Nil(t, lockedDB.Open(path.Join(destParentDir, "C")))
+	defer func() {
+		_ = lockedDB.Close()
+	}()
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "DBs locked by another process: "+path.Join(destParentDir, "C"))
+
+	// nothing should have been written
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key4": "B-value-d-4",
+	}
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+}
+
+func TestDBCopyRecursive(t *testing.T) {
+	srcParentDir := t.TempDir()
+	destParentDir := t.TempDir()
+
+	putData(t, path.Join(srcParentDir, "db", "1", "Epoch_1", "Shard_0", "MiniBlocks"), []string{"key1", "key2"}, []string{"s-1-1", "s-1-2"})
+	putData(t, path.Join(srcParentDir, "db", "1", "Epoch_2", "Shard_0", "MiniBlocks"), []string{"key1", "key2"}, []string{"s-2-1", "s-2-2"})
+	putData(t, path.Join(srcParentDir, "db", "1", "Static", "Shard_0", "MiniBlocks"), []string{"key1"}, []string{"s-static-1"})
+
+	putData(t, path.Join(destParentDir, "db", "1", "Epoch_1", "Shard_0", "MiniBlocks"), []string{"key2"}, []string{"d-1-2"})
+	putData(t, path.Join(destParentDir, "db", "1", "Static", "Shard_0", "MiniBlocks"), []string{"key2"}, []string{"d-static-2"})
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+		Recursive:        true,
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         2,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedEpoch1Data := map[string]string{
+		"key1": "s-1-1",
+		"key2": "d-1-2",
+	}
+	expectedStaticData := map[string]string{
+		"key1": "s-static-1",
+		"key2": "d-static-2",
+	}
+	assert.Equal(t, expectedEpoch1Data, getAllData(t, path.Join(destParentDir, "db", "1", "Epoch_1", "Shard_0", "MiniBlocks")))
+	assert.Equal(t, expectedStaticData, getAllData(t, path.Join(destParentDir, "db", "1", "Static", "Shard_0", "MiniBlocks")))
+	assert.Nil(t, getAllData(t, path.Join(destParentDir, "db", "1", "Epoch_2")))
+}
+
+func TestDBCopyBidirectional(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+		Bidirectional:      true,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedDestBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key3": "B-value-s-3", // copied from src
+		"B-key4": "B-value-d-4",
+	}
+	expectedSrcCdata := map[string]string{
+		"C-key1": "C-value-s-1",
+		"C-key2": "C-value-s-2",
+		"C-key3": "C-value-d-3", // copied from dest
+	}
+	expectedSrcBdata := map[string]string{
+		"B-key1": "B-value-s-1",
+		"B-key2": "B-value-s-2",
+		"B-key3": "B-value-s-3",
+		"B-key4": "B-value-s-4",
+	}
+
+	assert.Equal(t, expectedDestBdata, getAllData(t, path.Join(destParentDir, "B")))
+	assert.Equal(t, expectedSrcBdata, getAllData(t, path.Join(srcParentDir, "B")))
+	assert.Equal(t, expectedSrcCdata, getAllData(t, path.Join(srcParentDir, "C")))
+}
+
+func TestDBCopyMirror(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.OverwriteWithSourcePolicy)
+	assert.Nil(t, err)
+
+	args := process.ArgsDataCopyHandler{
+		DirectoriesHandler:  dirHandler,
+		DBWrapperFactory:    process.NewDBWrapperFactory(),
+		NumWorkers:          1,
+		ConflictResolver:    conflictResolver,
+		CheckpointHandler:   process.NewDisabledCheckpointHandler(),
+		LockChecker:         process.NewLockChecker(),
+		BatchSize:           2,
+		CopyStrategy:        process.MergeJoinCopyStrategy,
+		Mirror:              true,
+		MirrorConfirmed:     true,
+		MaxDeletionsPercent: 10,
+	}
+	copyHandler, err := process.NewDataCopyHandler(args)
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "deletions cap exceeded")
+	assert.Len(t, getAllData(t, path.Join(destParentDir, "C")), 3)
+
+	args.MaxDeletionsPercent = 50
+	copyHandler, err = process.NewDataCopyHandler(args)
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	for _, name := range []string{"A", "B", "C", "D"} {
+		assert.Equal(t, getAllData(t, path.Join(srcParentDir, name)), getAllData(t, path.Join(destParentDir, name)))
+	}
+}
+
+func TestDBCopyWithMultipleSources(t *testing.T) {
+	for _, strategy := range process.CopyStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			testDBCopyWithMultipleSources(t, strategy)
+		})
+	}
+}
+
+func testDBCopyWithMultipleSources(t *testing.T, strategy string) {
+	srcParentDir, destParentDir := setupDirs(t)
+	secondSrcParentDir := t.TempDir()
+	putData(t,
+		path.Join(secondSrcParentDir, "A"),
+		[]string{"A-key1", "A-key4"},
+		[]string{"A-value-s2-1", "A-value-s2-4"},
+	)
+	putData(t,
+		path.Join(secondSrcParentDir, "B"),
+		[]string{"B-key3", "B-key5"},
+		[]string{"B-value-s2-3", "B-value-s2-5"},
+	)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir, secondSrcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       strategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedAdata := map[string]string{
+		"A-key1": "A-value-d-1",
+		"A-key2": "A-value-d-2",
+		"A-key3": "A-value-d-3",
+		"A-key4": "A-value-s2-4", // copied from the second source
+	}
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key3": "B-value-s-3", // copied from the first source
+		"B-key4": "B-value-d-4",
+		"B-key5": "B-value-s2-5", // copied from the second source
+	}
+
+	assert.Equal(t, expectedAdata, getAllData(t, path.Join(destParentDir, "A")))
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+}
+
+func TestDBCopyToMultipleDestinations(t *testing.T) {
+	for _, strategy := range process.CopyStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			testDBCopyToMultipleDestinations(t, strategy)
+		})
+	}
+}
+
+func testDBCopyToMultipleDestinations(t *testing.T, strategy string) {
+	srcParentDir, destParentDir := setupDirs(t)
+	secondDestParentDir := t.TempDir()
+	putData(t,
+		path.Join(secondDestParentDir, "B"),
+		[]string{"B-key2"},
+		[]string{"B-value-d2-2"},
+	)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir, secondDestParentDir},
+	})
+	assert.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	assert.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         2,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CreateMissing:      true,
+		CopyStrategy:       strategy,
+	})
+	assert.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	expectedBdata := map[string]string{
+		"B-key1": "B-value-d-1",
+		"B-key2": "B-value-d-2",
+		"B-key3": "B-value-s-3", // copied from source
+		"B-key4": "B-value-d-4",
+	}
+	assert.Equal(t, expectedBdata, getAllData(t, path.Join(destParentDir, "B")))
+
+	expectedSecondBdata := map[string]string{
+		"B-key1": "B-value-s-1", // copied from source
+		"B-key2": "B-value-d2-2",
+		"B-key3": "B-value-s-3", // copied from source
+		"B-key4": "B-value-s-4", // copied from source
+	}
+	assert.Equal(t, expectedSecondBdata, getAllData(t, path.Join(secondDestParentDir, "B")))
+
+	// the DBs missing from the second destination are created
+	expectedEdata := map[string]string{
+		"E-key1": "E-value-s-1",
+	}
+	assert.Equal(t, expectedEdata, getAllData(t, path.Join(destParentDir, "E")))
+	assert.Equal(t, expectedEdata, getAllData(t, path.Join(secondDestParentDir, "E")))
+	assert.Equal(t, 3, len(getAllData(t, path.Join(secondDestParentDir, "A"))))
+	assert.Equal(t, 2, len(getAllData(t, path.Join(secondDestParentDir, "C"))))
 }
 
 func setupDirs(t *testing.T) (string, string) {
@@ -152,5 +814,78 @@ func getAllData(t *testing.T, path string) map[string]string {
 		return true
 	})
 
+	err = wrapper.Close()
+	require.Nil(t, err)
+
+	return result
+}
+
+func getFilesContent(t *testing.T, dir string) map[string]string {
+	result := make(map[string]string)
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, errRead := os.ReadFile(filePath)
+		result[filePath] = string(content)
+
+		return errRead
+	})
+	require.Nil(t, err)
+
 	return result
 }
+
+func TestDBCopyDryRunShouldNotChangeAnyFile(t *testing.T) {
+	for _, strategy := range process.CopyStrategies() {
+		t.Run(strategy, func(t *testing.T) {
+			testDBCopyDryRun(t, strategy, false)
+		})
+	}
+	t.Run("bidirectional", func(t *testing.T) {
+		testDBCopyDryRun(t, process.MergeJoinCopyStrategy, true)
+	})
+}
+
+func testDBCopyDryRun(t *testing.T, strategy string, isBidirectional bool) {
+	srcParentDir, destParentDir := setupDirs(t)
+	// a destination sub-directory that is not a level DB
+	err := os.MkdirAll(path.Join(destParentDir, "E"), 0700)
+	require.Nil(t, err)
+	err = os.WriteFile(path.Join(destParentDir, "E", "notes.txt"), []byte("not a DB"), 0600)
+	require.Nil(t, err)
+
+	srcContentBefore := getFilesContent(t, srcParentDir)
+	destContentBefore := getFilesContent(t, destParentDir)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.OverwriteWithSourcePolicy)
+	require.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       strategy,
+		CreateMissing:      !isBidirectional,
+		Bidirectional:      isBidirectional,
+		DryRun:             true,
+	})
+	require.Nil(t, err)
+
+	err = copyHandler.Process()
+	assert.Nil(t, err)
+
+	assert.Equal(t, srcContentBefore, getFilesContent(t, srcParentDir))
+	assert.Equal(t, destContentBefore, getFilesContent(t, destParentDir))
+}
@@ -0,0 +1,125 @@
+package integrationTests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"path"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/process"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBDiff(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+	srcContentBefore := getFilesContent(t, srcParentDir)
+	destContentBefore := getFilesContent(t, destParentDir)
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	diffHandler, err := process.NewDiffHandler(process.ArgsDiffHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		MaxListedKeys:      10,
+	})
+	require.Nil(t, err)
+
+	report, err := diffHandler.Process()
+	require.Nil(t, err)
+
+	require.Equal(t, 4, len(report.DBs))
+	dbDiffA := report.DBs[0]
+	assert.Equal(t, "A", dbDiffA.Name)
+	assert.Equal(t, process.DiffCounters{Different: 3}, dbDiffA.DiffCounters)
+
+	dbDiffB := report.DBs[1]
+	assert.Equal(t, process.DiffCounters{OnlyInSource: 1, Different: 3}, dbDiffB.DiffCounters)
+	assert.Equal(t, []string{hex.EncodeToString([]byte("B-key3"))}, dbDiffB.OnlyInSourceKeys)
+
+	dbDiffC := report.DBs[2]
+	assert.Equal(t, process.DiffCounters{OnlyInDestination: 1, Different: 2}, dbDiffC.DiffCounters)
+	assert.Equal(t, []string{hex.EncodeToString([]byte("C-key3"))}, dbDiffC.OnlyInDestinationKeys)
+
+	assert.False(t, report.DBs[3].HasDifferences())
+	assert.Equal(t, path.Join(destParentDir, "D"), report.DBs[3].DestinationPath)
+	assert.Equal(t, process.DiffCounters{OnlyInSource: 1, OnlyInDestination: 1, Different: 8}, report.Total)
+	assert.Equal(t, []string{"E"}, report.SourceOnlyDBs)
+	assert.Equal(t, []string{"F"}, report.DestinationOnlyDBs)
+
+	buff := &bytes.Buffer{}
+	err = process.WriteDiffReport(buff, report, process.TextOutputFormat)
+	assert.Nil(t, err)
+	assert.Contains(t, buff.String(), "DB D: identical")
+
+	// the diff should never change any side
+	assert.Equal(t, srcContentBefore, getFilesContent(t, srcParentDir))
+	assert.Equal(t, destContentBefore, getFilesContent(t, destParentDir))
+}
+
+func TestDBVerify(t *testing.T) {
+	srcParentDir, destParentDir := setupDirs(t)
+
+	createReport := func() *process.DiffReport {
+		dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+			SourceParentDirs: []string{srcParentDir},
+			DestParentDirs:   []string{destParentDir},
+		})
+		require.Nil(t, err)
+
+		diffHandler, err := process.NewDiffHandler(process.ArgsDiffHandler{
+			DirectoriesHandler: dirHandler,
+			DBWrapperFactory:   process.NewDBWrapperFactory(),
+			MaxListedKeys:      10,
+		})
+		require.Nil(t, err)
+
+		report, err := diffHandler.Process()
+		require.Nil(t, err)
+
+		return report
+	}
+
+	report := createReport()
+	err := report.Verify(false)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "for 1/4 DBs: B")
+
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+		DestParentDirs:   []string{destParentDir},
+	})
+	require.Nil(t, err)
+
+	conflictResolver, err := process.NewConflictResolver(process.KeepDestinationPolicy)
+	require.Nil(t, err)
+
+	copyHandler, err := process.NewDataCopyHandler(process.ArgsDataCopyHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		NumWorkers:         1,
+		ConflictResolver:   conflictResolver,
+		CheckpointHandler:  process.NewDisabledCheckpointHandler(),
+		LockChecker:        process.NewLockChecker(),
+		BatchSize:          2,
+		CopyStrategy:       process.MergeJoinCopyStrategy,
+	})
+	require.Nil(t, err)
+
+	err = copyHandler.Process()
+	require.Nil(t, err)
+
+	// all the source keys are now present, the conflicting values were kept
+	report = createReport()
+	err = report.Verify(false)
+	assert.Nil(t, err)
+
+	err = report.Verify(true)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "for 3/4 DBs: A, B, C")
+}
@@ -0,0 +1,79 @@
+package integrationTests
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/process"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpLoadRoundTrip(t *testing.T) {
+	for _, format := range process.RecordFormats() {
+		for _, encoding := range []string{process.HexEncoding, process.Base64Encoding, process.AutoEncoding} {
+			t.Run(fmt.Sprintf("%s %s", format, encoding), func(t *testing.T) {
+				testDumpLoadRoundTrip(t, format, encoding)
+			})
+		}
+	}
+}
+
+func testDumpLoadRoundTrip(t *testing.T, format string, encoding string) {
+	srcParentDir, _ := setupDirs(t)
+	putData(t,
+		path.Join(srcParentDir, "G"),
+		[]string{string([]byte{0x00, 0xff}), "G-key,2", "G-key\n3"},
+		[]string{"G-value-s-1", string([]byte{0xfe, 0x01}), ""},
+	)
+
+	dump := dumpRecords(t, srcParentDir, format, encoding)
+
+	conflictResolver, err := process.NewConflictResolver(process.FailPolicy)
+	require.Nil(t, err)
+
+	loadedParentDir := t.TempDir()
+	loadHandler, err := process.NewLoadHandler(process.ArgsLoadHandler{
+		DBWrapperFactory: process.NewDBWrapperFactory(),
+		ConflictResolver: conflictResolver,
+		DestParentDir:    loadedParentDir,
+		RecordFormat:     format,
+		KeyEncoding:      process.UTF8Encoding,
+		ValueEncoding:    process.UTF8Encoding,
+		CreateMissing:    true,
+	})
+	require.Nil(t, err)
+
+	err = loadHandler.Process(bytes.NewReader(dump))
+	require.Nil(t, err)
+
+	for _, name := range []string{"A", "B", "C", "G"} {
+		assert.Equal(t, getAllData(t, path.Join(srcParentDir, name)), getAllData(t, path.Join(loadedParentDir, name)), name)
+	}
+	assert.Equal(t, string(dump), string(dumpRecords(t, loadedParentDir, format, encoding)))
+}
+
+func dumpRecords(t *testing.T, srcParentDir string, format string, encoding string) []byte {
+	dirHandler, err := process.NewDirectoriesHandler(process.ArgsDirectoriesHandler{
+		SourceParentDirs: []string{srcParentDir},
+	})
+	require.Nil(t, err)
+
+	dumpHandler, err := process.NewDumpHandler(process.ArgsDumpHandler{
+		DirectoriesHandler: dirHandler,
+		DBWrapperFactory:   process.NewDBWrapperFactory(),
+		RecordFormat:       format,
+		KeyEncoding:        encoding,
+		ValueEncoding:      encoding,
+	})
+	require.Nil(t, err)
+
+	buff := &bytes.Buffer{}
+	err = dumpHandler.Process(buff)
+	require.Nil(t, err)
+
+	return buff.Bytes()
+}
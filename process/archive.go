@@ -0,0 +1,333 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// The archive file starts with a header (the magic bytes and the format version), followed by a section for each DB
+// and by the archive end marker. A section starts with the section marker and the DB name, holds one record for each
+// key and ends with the section end marker and the number of records. The names, keys and values are prefixed by their
+// length (4 bytes, big endian). The archive ends with the SHA-256 checksum of all the previous bytes
+const (
+	// ArchiveVersion is the version of the archive format written by this tool
+	ArchiveVersion = uint32(1)
+
+	archiveMagic       = "LDBCARCH"
+	sectionMarker      = byte(1)
+	recordMarker       = byte(2)
+	sectionEndMarker   = byte(3)
+	archiveEndMarker   = byte(4)
+	maxArchiveFieldLen = 1 << 30
+)
+
+// archiveWriter streams DB sections in an archive, computing the checksum on the fly
+type archiveWriter struct {
+	writer        *bufio.Writer
+	hasher        hash.Hash
+	out           io.Writer
+	inSection     bool
+	numRecords    uint64
+	lengthScratch [8]byte
+}
+
+func newArchiveWriter(writer io.Writer) (*archiveWriter, error) {
+	aw := &archiveWriter{
+		writer: bufio.NewWriter(writer),
+		hasher: sha256.New(),
+	}
+	aw.out = io.MultiWriter(aw.writer, aw.hasher)
+
+	_, err := aw.out.Write([]byte(archiveMagic))
+	if err != nil {
+		return nil, err
+	}
+	err = aw.writeUint32(ArchiveVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+func (aw *archiveWriter) startSection(name string) error {
+	if aw.inSection {
+		return fmt.Errorf("%w: section %s started before ending the previous one", errInvalidArchive, name)
+	}
+
+	aw.inSection = true
+	aw.numRecords = 0
+	err := aw.writeMarker(sectionMarker)
+	if err != nil {
+		return err
+	}
+
+	return aw.writeField([]byte(name))
+}
+
+func (aw *archiveWriter) writeRecord(key []byte, value []byte) error {
+	if !aw.inSection {
+		return fmt.Errorf("%w: record written outside a section", errInvalidArchive)
+	}
+
+	aw.numRecords++
+	err := aw.writeMarker(recordMarker)
+	if err != nil {
+		return err
+	}
+	err = aw.writeField(key)
+	if err != nil {
+		return err
+	}
+
+	return aw.writeField(value)
+}
+
+func (aw *archiveWriter) endSection() error {
+	aw.inSection = false
+	err := aw.writeMarker(sectionEndMarker)
+	if err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint64(aw.lengthScratch[:], aw.numRecords)
+	_, err = aw.out.Write(aw.lengthScratch[:])
+
+	return err
+}
+
+// close writes the archive end marker and the checksum. The underlying writer is not closed
+func (aw *archiveWriter) close() error {
+	if aw.inSection {
+		return fmt.Errorf("%w: archive closed inside a section", errInvalidArchive)
+	}
+
+	err := aw.writeMarker(archiveEndMarker)
+	if err != nil {
+		return err
+	}
+
+	_, err = aw.writer.Write(aw.hasher.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	return aw.writer.Flush()
+}
+
+func (aw *archiveWriter) writeMarker(marker byte) error {
+	_, err := aw.out.Write([]byte{marker})
+	return err
+}
+
+func (aw *archiveWriter) writeField(field []byte) error {
+	err := aw.writeUint32(uint32(len(field)))
+	if err != nil {
+		return err
+	}
+
+	_, err = aw.out.Write(field)
+
+	return err
+}
+
+func (aw *archiveWriter) writeUint32(value uint32) error {
+	binary.BigEndian.PutUint32(aw.lengthScratch[:4], value)
+	_, err := aw.out.Write(aw.lengthScratch[:4])
+
+	return err
+}
+
+// archiveSection holds the records of a DB read from an archive, in the order they were written (ascending key order)
+type archiveSection struct {
+	name   string
+	keys   [][]byte
+	values [][]byte
+}
+
+// archiveReader reads the sections of an archive in a single sequential pass. The checksum is verified once
+// the last section is read
+type archiveReader struct {
+	reader  *bufio.Reader
+	hasher  hash.Hash
+	in      io.Reader
+	version uint32
+	ended   bool
+	offset  int64
+
+	sectionName string
+	numRecords  uint64
+}
+
+func newArchiveReader(reader io.Reader) (*archiveReader, error) {
+	ar := &archiveReader{
+		reader: bufio.NewReader(reader),
+		hasher: sha256.New(),
+	}
+	ar.in = io.TeeReader(ar.reader, ar.hasher)
+
+	magic := make([]byte, len(archiveMagic))
+	err := ar.readFull(magic)
+	if err != nil {
+		return nil, fmt.Errorf("%w while reading the header", err)
+	}
+	if string(magic) != archiveMagic {
+		return nil, fmt.Errorf("%w: unknown file header", errInvalidArchive)
+	}
+
+	ar.version, err = ar.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if ar.version != ArchiveVersion {
+		return nil, fmt.Errorf("%w: version %d, supported version %d", errUnsupportedArchiveVersion, ar.version, ArchiveVersion)
+	}
+
+	return ar, nil
+}
+
+// newArchiveSectionReader reads the records of the named section, the reader being positioned on its first record.
+// The checksum is not verified
+func newArchiveSectionReader(reader io.Reader, sectionName string) *archiveReader {
+	ar := &archiveReader{
+		reader:      bufio.NewReader(reader),
+		sectionName: sectionName,
+	}
+	ar.in = ar.reader
+
+	return ar
+}
+
+// nextSectionName reads the name of the next DB section, the reader being then positioned on its first record.
+// Returns false after the last section, once the checksum was verified
+func (ar *archiveReader) nextSectionName() (string, bool, error) {
+	if ar.ended {
+		return "", false, nil
+	}
+
+	marker, err := ar.readMarker()
+	if err != nil {
+		return "", false, err
+	}
+	if marker == archiveEndMarker {
+		ar.ended = true
+		return "", false, ar.verifyChecksum()
+	}
+	if marker != sectionMarker {
+		return "", false, fmt.Errorf("%w: unexpected marker %d, expected a section", errInvalidArchive, marker)
+	}
+
+	name, err := ar.readField()
+	if err != nil {
+		return "", false, err
+	}
+
+	ar.sectionName = string(name)
+	ar.numRecords = 0
+
+	return ar.sectionName, true, nil
+}
+
+// nextRecord reads the next record of the current section. Returns false once the section end is read and the
+// number of records is checked
+func (ar *archiveReader) nextRecord() ([]byte, []byte, bool, error) {
+	marker, err := ar.readMarker()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if marker == sectionEndMarker {
+		return nil, nil, false, ar.checkNumRecords()
+	}
+	if marker != recordMarker {
+		return nil, nil, false, fmt.Errorf("%w: unexpected marker %d in section %s", errInvalidArchive, marker, ar.sectionName)
+	}
+
+	key, err := ar.readField()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	value, err := ar.readField()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ar.numRecords++
+
+	return key, value, true, nil
+}
+
+func (ar *archiveReader) checkNumRecords() error {
+	buff := make([]byte, 8)
+	err := ar.readFull(buff)
+	if err != nil {
+		return err
+	}
+
+	numRecords := binary.BigEndian.Uint64(buff)
+	if numRecords != ar.numRecords {
+		return fmt.Errorf("%w: section %s holds %d records, expected %d", errInvalidArchive, ar.sectionName,
+			ar.numRecords, numRecords)
+	}
+
+	return nil
+}
+
+func (ar *archiveReader) verifyChecksum() error {
+	expectedChecksum := ar.hasher.Sum(nil)
+	checksum := make([]byte, len(expectedChecksum))
+	_, err := io.ReadFull(ar.reader, checksum)
+	if err != nil {
+		return fmt.Errorf("%w: %s while reading the checksum", errInvalidArchive, err.Error())
+	}
+	if !bytes.Equal(checksum, expectedChecksum) {
+		return errArchiveChecksumMismatch
+	}
+
+	return nil
+}
+
+func (ar *archiveReader) readMarker() (byte, error) {
+	buff := make([]byte, 1)
+	err := ar.readFull(buff)
+
+	return buff[0], err
+}
+
+func (ar *archiveReader) readField() ([]byte, error) {
+	length, err := ar.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if length > maxArchiveFieldLen {
+		return nil, fmt.Errorf("%w: field length %d exceeds the maximum %d", errInvalidArchive, length, maxArchiveFieldLen)
+	}
+
+	field := make([]byte, length)
+	err = ar.readFull(field)
+
+	return field, err
+}
+
+func (ar *archiveReader) readUint32() (uint32, error) {
+	buff := make([]byte, 4)
+	err := ar.readFull(buff)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(buff), nil
+}
+
+func (ar *archiveReader) readFull(buff []byte) error {
+	n, err := io.ReadFull(ar.in, buff)
+	ar.offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errInvalidArchive, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"iulianpascalau/level-db-copy-go/common"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// archiveDBWrapper is a read-only DB backed by an archive section, read straight from the archive file
+type archiveDBWrapper struct {
+	mutDB    sync.RWMutex
+	path     string
+	filePath string
+	info     archiveSectionInfo
+	opened   bool
+}
+
+func newArchiveDBWrapper(path string, filePath string, info archiveSectionInfo) *archiveDBWrapper {
+	return &archiveDBWrapper{
+		path:     path,
+		filePath: filePath,
+		info:     info,
+	}
+}
+
+// Open opens the archive section. Errors if the provided path is not the section path
+func (wrapper *archiveDBWrapper) Open(path string) error {
+	wrapper.mutDB.Lock()
+	defer wrapper.mutDB.Unlock()
+
+	if wrapper.opened {
+		return errInnerDBIsNotClosed
+	}
+	if path != wrapper.path {
+		return fmt.Errorf("%w: path %s, section path %s", errUnknownArchiveSection, path, wrapper.path)
+	}
+
+	wrapper.opened = true
+
+	return nil
+}
+
+// RangeKeys will call the provided handler for each key and value found in the archive section
+func (wrapper *archiveDBWrapper) RangeKeys(handler func(key []byte, val []byte) bool) {
+	iterator, err := wrapper.NewIterator(nil, nil)
+	if err != nil {
+		return
+	}
+	defer iterator.Release()
+
+	for iterator.Next() {
+		shouldContinue := handler(iterator.Key(), iterator.Value())
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// NewIterator returns an iterator over the keys from the [startKey, endKey) range. A nil start key means the first
+// key and a nil end key means past the last key. Each iterator reads the section records from its own file handle
+func (wrapper *archiveDBWrapper) NewIterator(startKey []byte, endKey []byte) (common.DBIterator, error) {
+	wrapper.mutDB.RLock()
+	defer wrapper.mutDB.RUnlock()
+
+	if !wrapper.opened {
+		return nil, errInnerDBIsNotOpened
+	}
+
+	return newArchiveSectionIterator(wrapper.filePath, wrapper.path, wrapper.info, startKey, endKey)
+}
+
+// SnapshotInfo returns nil as an archive section never changes
+func (wrapper *archiveDBWrapper) SnapshotInfo() *common.SnapshotInfo {
+	return nil
+}
+
+// Get gets the value associated to the key. The section records are read up to the key, as the archive has no index
+func (wrapper *archiveDBWrapper) Get(key []byte) ([]byte, error) {
+	iterator, err := wrapper.NewIterator(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+
+	if iterator.Next() && bytes.Equal(iterator.Key(), key) {
+		return iterator.Value(), nil
+	}
+	err = iterator.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, leveldb.ErrNotFound
+}
+
+// Put returns the read-only error
+func (wrapper *archiveDBWrapper) Put(_ []byte, _ []byte) error {
+	return errReadOnlyDB
+}
+
+// WriteBatch returns the read-only error
+func (wrapper *archiveDBWrapper) WriteBatch(_ [][]byte, _ [][]byte, _ [][]byte, _ bool) error {
+	return errReadOnlyDB
+}
+
+// Remove returns the read-only error
+func (wrapper *archiveDBWrapper) Remove(_ []byte) error {
+	return errReadOnlyDB
+}
+
+// Close closes the archive section, which can be opened again
+func (wrapper *archiveDBWrapper) Close() error {
+	wrapper.mutDB.Lock()
+	defer wrapper.mutDB.Unlock()
+
+	if !wrapper.opened {
+		return errInnerDBIsNotOpened
+	}
+
+	wrapper.opened = false
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (wrapper *archiveDBWrapper) IsInterfaceNil() bool {
+	return wrapper == nil
+}
+
+// archiveSectionIterator streams the records of an archive section, written in ascending key order. Seeking
+// backwards reads the section again from its first record
+type archiveSectionIterator struct {
+	file        *os.File
+	sectionPath string
+	info        archiveSectionInfo
+	startKey    []byte
+	endKey      []byte
+
+	reader  *archiveReader
+	started bool
+	ended   bool
+	key     []byte
+	value   []byte
+	err     error
+}
+
+func newArchiveSectionIterator(
+	filePath string,
+	sectionPath string,
+	info archiveSectionInfo,
+	startKey []byte,
+	endKey []byte,
+) (*archiveSectionIterator, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &archiveSectionIterator{
+		file:        file,
+		sectionPath: sectionPath,
+		info:        info,
+		startKey:    startKey,
+		endKey:      endKey,
+	}
+	err = it.rewind()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return it, nil
+}
+
+func (it *archiveSectionIterator) rewind() error {
+	_, err := it.file.Seek(it.info.offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	it.reader = newArchiveSectionReader(it.file, it.sectionPath)
+	it.started = false
+	it.ended = false
+	it.key = nil
+	it.value = nil
+
+	return nil
+}
+
+// Seek moves the iterator to the first key greater or equal to the provided key
+func (it *archiveSectionIterator) Seek(key []byte) bool {
+	canMoveForward := !it.started || (it.key != nil && bytes.Compare(it.key, key) < 0)
+	if !canMoveForward {
+		it.err = it.rewind()
+		if it.err != nil {
+			return false
+		}
+	}
+
+	for it.Next() {
+		if bytes.Compare(it.key, key) >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next moves the iterator to the next key of the range
+func (it *archiveSectionIterator) Next() bool {
+	it.started = true
+	for !it.ended && it.err == nil {
+		key, value, isRecord, err := it.reader.nextRecord()
+		if err != nil {
+			it.err = fmt.Errorf("%w, section %s", err, it.sectionPath)
+			break
+		}
+		if !isRecord {
+			break
+		}
+		if it.startKey != nil && bytes.Compare(key, it.startKey) < 0 {
+			continue
+		}
+		if it.endKey != nil && bytes.Compare(key, it.endKey) >= 0 {
+			break
+		}
+
+		it.key = key
+		it.value = value
+		return true
+	}
+
+	it.ended = true
+	it.key = nil
+	it.value = nil
+
+	return false
+}
+
+// Key returns the current key
+func (it *archiveSectionIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the current value
+func (it *archiveSectionIterator) Value() []byte {
+	return it.value
+}
+
+// Error returns the error encountered while reading the section, if any
+func (it *archiveSectionIterator) Error() error {
+	return it.err
+}
+
+// Release closes the archive file handle
+func (it *archiveSectionIterator) Release() {
+	_ = it.file.Close()
+}
@@ -0,0 +1,193 @@
+package process
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// createArchiveDBWrapper writes an archive holding the section A after another section, so the section is not read
+// from the start of the file
+func createArchiveDBWrapper(t *testing.T, keysAndValues ...string) *archiveDBWrapper {
+	section := &archiveSection{name: "A"}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		section.keys = append(section.keys, []byte(keysAndValues[i]))
+		section.values = append(section.values, []byte(keysAndValues[i+1]))
+	}
+	other := &archiveSection{
+		name:   "0",
+		keys:   [][]byte{[]byte("a"), []byte("z")},
+		values: [][]byte{[]byte("other"), []byte("other")},
+	}
+
+	source, err := NewArchiveSource(createArchiveFile(t, other, section), NewDBWrapperFactory())
+	require.Nil(t, err)
+	wrapper, err := source.Create(path.Join(source.Path(), "A"), SourceRole)
+	require.Nil(t, err)
+
+	return wrapper.(*archiveDBWrapper)
+}
+
+func TestArchiveDBWrapper_Open(t *testing.T) {
+	t.Parallel()
+
+	t.Run("other path should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t)
+		err := wrapper.Open(path.Join(wrapper.filePath, "B"))
+		assert.ErrorIs(t, err, errUnknownArchiveSection)
+	})
+	t.Run("open twice should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t)
+		assert.Nil(t, wrapper.Open(wrapper.path))
+		assert.Equal(t, errInnerDBIsNotClosed, wrapper.Open(wrapper.path))
+	})
+	t.Run("not opened should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t, "a", "1")
+		iterator, err := wrapper.NewIterator(nil, nil)
+		assert.Nil(t, iterator)
+		assert.Equal(t, errInnerDBIsNotOpened, err)
+
+		val, err := wrapper.Get([]byte("a"))
+		assert.Nil(t, val)
+		assert.Equal(t, errInnerDBIsNotOpened, err)
+		assert.Equal(t, errInnerDBIsNotOpened, wrapper.Close())
+	})
+	t.Run("should reopen after close", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t, "a", "1")
+		assert.Nil(t, wrapper.Open(wrapper.path))
+		assert.Nil(t, wrapper.Close())
+		assert.Nil(t, wrapper.Open(wrapper.path))
+
+		val, err := wrapper.Get([]byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("1"), val)
+	})
+}
+
+func TestArchiveDBWrapper_Read(t *testing.T) {
+	t.Parallel()
+
+	wrapper := createArchiveDBWrapper(t, "a", "1", "b", "2", "c", "3")
+	_ = wrapper.Open(wrapper.path)
+
+	t.Run("iterator should use the key range", func(t *testing.T) {
+		t.Parallel()
+
+		iterator, err := wrapper.NewIterator([]byte("b"), []byte("c"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b:2"}, readAll(iterator))
+		iterator.Release()
+	})
+	t.Run("iterator should seek forward and backward", func(t *testing.T) {
+		t.Parallel()
+
+		iterator, err := wrapper.NewIterator(nil, []byte("c"))
+		require.Nil(t, err)
+		defer iterator.Release()
+
+		assert.True(t, iterator.Seek([]byte("b")))
+		assert.Equal(t, []byte("b"), iterator.Key())
+		assert.Equal(t, []byte("2"), iterator.Value())
+		assert.True(t, iterator.Seek([]byte("0")))
+		assert.Equal(t, []byte("a"), iterator.Key())
+		assert.False(t, iterator.Seek([]byte("c")))
+		assert.Nil(t, iterator.Key())
+		assert.True(t, iterator.Seek([]byte("a")))
+		assert.Equal(t, []byte("a"), iterator.Key())
+		assert.Equal(t, []string{"b:2"}, readAll(iterator))
+		assert.Nil(t, iterator.Error())
+	})
+	t.Run("range keys should read all the keys", func(t *testing.T) {
+		t.Parallel()
+
+		keys := make([]string, 0)
+		wrapper.RangeKeys(func(key []byte, val []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		assert.Equal(t, []string{"a", "b", "c"}, keys)
+	})
+	t.Run("missing key should return not found", func(t *testing.T) {
+		t.Parallel()
+
+		val, err := wrapper.Get([]byte("d"))
+		assert.Nil(t, val)
+		assert.Equal(t, leveldb.ErrNotFound, err)
+	})
+	t.Run("key of another section should return not found", func(t *testing.T) {
+		t.Parallel()
+
+		val, err := wrapper.Get([]byte("z"))
+		assert.Nil(t, val)
+		assert.Equal(t, leveldb.ErrNotFound, err)
+	})
+	t.Run("writes should error", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, errReadOnlyDB, wrapper.Put([]byte("d"), []byte("4")))
+		assert.Equal(t, errReadOnlyDB, wrapper.WriteBatch([][]byte{[]byte("d")}, [][]byte{[]byte("4")}, nil, false))
+		assert.Equal(t, errReadOnlyDB, wrapper.Remove([]byte("a")))
+		assert.Nil(t, wrapper.SnapshotInfo())
+	})
+}
+
+func TestArchiveDBWrapper_ReadErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing archive file should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t, "a", "1")
+		require.Nil(t, wrapper.Open(wrapper.path))
+		require.Nil(t, os.Remove(wrapper.filePath))
+
+		iterator, err := wrapper.NewIterator(nil, nil)
+		assert.Nil(t, iterator)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		val, err := wrapper.Get([]byte("a"))
+		assert.Nil(t, val)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("truncated archive file should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := createArchiveDBWrapper(t, "a", "1", "b", "2")
+		require.Nil(t, wrapper.Open(wrapper.path))
+		require.Nil(t, os.Truncate(wrapper.filePath, wrapper.info.offset+4))
+
+		iterator, err := wrapper.NewIterator(nil, nil)
+		require.Nil(t, err)
+		defer iterator.Release()
+
+		assert.False(t, iterator.Next())
+		assert.ErrorIs(t, iterator.Error(), errInvalidArchive)
+		assert.Contains(t, iterator.Error().Error(), wrapper.path)
+
+		val, err := wrapper.Get([]byte("b"))
+		assert.Nil(t, val)
+		assert.ErrorIs(t, err, errInvalidArchive)
+	})
+}
+
+func TestArchiveDBWrapper_IsInterfaceNil(t *testing.T) {
+	t.Parallel()
+
+	var instance *archiveDBWrapper
+	assert.True(t, instance.IsInterfaceNil())
+
+	instance = createArchiveDBWrapper(t)
+	assert.False(t, instance.IsInterfaceNil())
+}
@@ -0,0 +1,143 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+)
+
+// archiveSectionInfo locates the records of an archive section in the archive file
+type archiveSectionInfo struct {
+	offset     int64
+	numRecords uint64
+}
+
+// archiveSource indexes the sections of an archive file and creates the read-only DB wrappers reading them straight
+// from the file. The DB wrappers for the paths outside the archive are created by the provided factory
+type archiveSource struct {
+	path         string
+	sectionPaths []string
+	sections     map[string]archiveSectionInfo
+	factory      DBWrapperFactory
+}
+
+// NewArchiveSource reads the provided archive file once, verifying its checksum and the records order, and creates a
+// new instance of type archive source. Only the sections positions are kept in memory. Each section is seen as a
+// source DB found under the archive file path
+func NewArchiveSource(filePath string, factory DBWrapperFactory) (*archiveSource, error) {
+	if check.IfNil(factory) {
+		return nil, errNilDBWrapperFactory
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader, err := newArchiveReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w, file %s", err, filePath)
+	}
+
+	source := &archiveSource{
+		path:         filePath,
+		sectionPaths: make([]string, 0),
+		sections:     make(map[string]archiveSectionInfo),
+		factory:      factory,
+	}
+	for {
+		name, found, errRead := reader.nextSectionName()
+		if errRead != nil {
+			return nil, fmt.Errorf("%w, file %s", errRead, filePath)
+		}
+		if !found {
+			break
+		}
+
+		err = source.indexSection(reader, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Info("indexed the source archive", "file", filePath, "sections", len(source.sectionPaths))
+
+	return source, nil
+}
+
+// indexSection records the position of the section records, skipping them while checking they are in ascending
+// key order
+func (source *archiveSource) indexSection(reader *archiveReader, name string) error {
+	// the archive may come from another host, so a section name can not point outside the archive path
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: section name %s is outside the archive, file %s", errInvalidArchive, name, source.path)
+	}
+
+	sectionPath := path.Join(source.path, name)
+	_, isDuplicated := source.sections[sectionPath]
+	if isDuplicated {
+		return fmt.Errorf("%w: duplicated section %s, file %s", errInvalidArchive, name, source.path)
+	}
+
+	info := archiveSectionInfo{
+		offset: reader.offset,
+	}
+	var previousKey []byte
+	for {
+		key, _, isRecord, err := reader.nextRecord()
+		if err != nil {
+			return fmt.Errorf("%w, file %s", err, source.path)
+		}
+		if !isRecord {
+			break
+		}
+		if info.numRecords > 0 && bytes.Compare(previousKey, key) >= 0 {
+			return fmt.Errorf("%w: the keys of section %s are not in ascending order, file %s", errInvalidArchive,
+				name, source.path)
+		}
+
+		previousKey = key
+		info.numRecords++
+	}
+
+	source.sections[sectionPath] = info
+	source.sectionPaths = append(source.sectionPaths, sectionPath)
+
+	return nil
+}
+
+// Path returns the archive file path
+func (source *archiveSource) Path() string {
+	return source.path
+}
+
+// SectionPaths returns the paths of the archive sections, in the archive order
+func (source *archiveSource) SectionPaths() []string {
+	return source.sectionPaths
+}
+
+// Create returns a read-only DB wrapper reading the archive section for the section paths, only usable as a source.
+// For all the other paths, the provided factory is used
+func (source *archiveSource) Create(dbPath string, role DBRole) (DBWrapper, error) {
+	info, found := source.sections[dbPath]
+	if !found {
+		return source.factory.Create(dbPath, role)
+	}
+	if role != SourceRole {
+		return nil, fmt.Errorf("%w: the archive section %s can not be used as %s", errReadOnlyDB, dbPath, role.String())
+	}
+
+	return newArchiveDBWrapper(dbPath, source.path, info), nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (source *archiveSource) IsInterfaceNil() bool {
+	return source == nil
+}
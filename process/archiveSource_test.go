@@ -0,0 +1,132 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArchiveFile(t *testing.T, sections ...*archiveSection) string {
+	filePath := filepath.Join(t.TempDir(), "export.ldbarch")
+	err := os.WriteFile(filePath, createArchive(t, sections...), 0600)
+	require.Nil(t, err)
+
+	return filePath
+}
+
+func TestNewArchiveSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		source, err := NewArchiveSource(createArchiveFile(t), nil)
+		assert.Nil(t, source)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("missing file should error", func(t *testing.T) {
+		t.Parallel()
+
+		source, err := NewArchiveSource(filepath.Join(t.TempDir(), "missing"), NewDBWrapperFactory())
+		assert.Nil(t, source)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+	t.Run("invalid archive should error", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), "export.ldbarch")
+		require.Nil(t, os.WriteFile(filePath, []byte("not an archive"), 0600))
+
+		source, err := NewArchiveSource(filePath, NewDBWrapperFactory())
+		assert.Nil(t, source)
+		assert.ErrorIs(t, err, errInvalidArchive)
+		assert.Contains(t, err.Error(), filePath)
+	})
+	t.Run("duplicated section should error", func(t *testing.T) {
+		t.Parallel()
+
+		source, err := NewArchiveSource(createArchiveFile(t, &archiveSection{name: "A"}, &archiveSection{name: "A"}), NewDBWrapperFactory())
+		assert.Nil(t, source)
+		assert.ErrorIs(t, err, errInvalidArchive)
+	})
+	t.Run("section name outside the archive should error", func(t *testing.T) {
+		t.Parallel()
+
+		for _, name := range []string{"../../escaped/DB", "/escaped/DB", "A/../../B", ""} {
+			source, err := NewArchiveSource(createArchiveFile(t, &archiveSection{name: name}), NewDBWrapperFactory())
+			assert.Nil(t, source, name)
+			assert.ErrorIs(t, err, errInvalidArchive, name)
+		}
+	})
+	t.Run("keys not in ascending order should error", func(t *testing.T) {
+		t.Parallel()
+
+		for _, keys := range [][][]byte{{[]byte("b"), []byte("a")}, {[]byte("a"), []byte("a")}} {
+			section := &archiveSection{
+				name:   "A",
+				keys:   keys,
+				values: [][]byte{[]byte("1"), []byte("2")},
+			}
+			source, err := NewArchiveSource(createArchiveFile(t, section), NewDBWrapperFactory())
+			assert.Nil(t, source)
+			assert.ErrorIs(t, err, errInvalidArchive)
+			assert.Contains(t, err.Error(), "ascending order")
+		}
+	})
+	t.Run("should index the sections", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := createArchiveFile(t, &archiveSection{name: "Epoch_1/Shard_0/A"}, &archiveSection{name: "B"})
+		source, err := NewArchiveSource(filePath, NewDBWrapperFactory())
+		assert.Nil(t, err)
+		assert.False(t, source.IsInterfaceNil())
+		assert.Equal(t, filePath, source.Path())
+		assert.Equal(t, []string{path.Join(filePath, "Epoch_1/Shard_0/A"), path.Join(filePath, "B")}, source.SectionPaths())
+	})
+}
+
+func TestArchiveSource_Create(t *testing.T) {
+	t.Parallel()
+
+	filePath := createArchiveFile(t, &archiveSection{
+		name:   "A",
+		keys:   [][]byte{[]byte("a1")},
+		values: [][]byte{[]byte("v1")},
+	})
+	expectedWrapper := &testcommon.DBWrapperStub{}
+	var createdPath string
+	source, _ := NewArchiveSource(filePath, &dbWrapperFactoryStub{
+		createCalled: func(path string, role DBRole) (DBWrapper, error) {
+			createdPath = path
+			return expectedWrapper, nil
+		},
+	})
+	sectionPath := path.Join(filePath, "A")
+
+	wrapper, err := source.Create(sectionPath, SourceRole)
+	assert.Nil(t, err)
+	require.Nil(t, wrapper.Open(sectionPath))
+	val, err := wrapper.Get([]byte("a1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v1"), val)
+
+	wrapper, err = source.Create(sectionPath, DestinationRole)
+	assert.Nil(t, wrapper)
+	assert.ErrorIs(t, err, errReadOnlyDB)
+
+	wrapper, err = source.Create(sectionPath, WritableSourceRole)
+	assert.Nil(t, wrapper)
+	assert.ErrorIs(t, err, errReadOnlyDB)
+
+	wrapper, err = source.Create("dest/A", DestinationRole)
+	assert.Nil(t, err)
+	assert.True(t, wrapper == expectedWrapper)
+	assert.Equal(t, "dest/A", createdPath)
+}
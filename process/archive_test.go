@@ -0,0 +1,145 @@
+package process
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArchive(t *testing.T, sections ...*archiveSection) []byte {
+	buff := &bytes.Buffer{}
+	archive, err := newArchiveWriter(buff)
+	require.Nil(t, err)
+
+	for _, section := range sections {
+		require.Nil(t, archive.startSection(section.name))
+		for i := range section.keys {
+			require.Nil(t, archive.writeRecord(section.keys[i], section.values[i]))
+		}
+		require.Nil(t, archive.endSection())
+	}
+	require.Nil(t, archive.close())
+
+	return buff.Bytes()
+}
+
+func readSections(data []byte) ([]*archiveSection, error) {
+	reader, err := newArchiveReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]*archiveSection, 0)
+	for {
+		name, found, errRead := reader.nextSectionName()
+		if errRead != nil {
+			return nil, errRead
+		}
+		if !found {
+			return sections, nil
+		}
+
+		section := &archiveSection{
+			name: name,
+		}
+		for {
+			key, value, isRecord, errRecord := reader.nextRecord()
+			if errRecord != nil {
+				return nil, errRecord
+			}
+			if !isRecord {
+				break
+			}
+
+			section.keys = append(section.keys, key)
+			section.values = append(section.values, value)
+		}
+		sections = append(sections, section)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	sections := []*archiveSection{
+		{
+			name:   "Epoch_1/Shard_0/A",
+			keys:   [][]byte{[]byte("a1"), []byte("a2")},
+			values: [][]byte{[]byte("v1"), make([]byte, 0)},
+		},
+		{
+			name: "B",
+		},
+	}
+
+	t.Run("should read the written sections", func(t *testing.T) {
+		t.Parallel()
+
+		readData, err := readSections(createArchive(t, sections...))
+		assert.Nil(t, err)
+		assert.Equal(t, sections, readData)
+	})
+	t.Run("empty archive should work", func(t *testing.T) {
+		t.Parallel()
+
+		readData, err := readSections(createArchive(t))
+		assert.Nil(t, err)
+		assert.Empty(t, readData)
+	})
+	t.Run("unknown header should error", func(t *testing.T) {
+		t.Parallel()
+
+		data := createArchive(t, sections...)
+		data[0] = 'X'
+		readData, err := readSections(data)
+		assert.Nil(t, readData)
+		assert.ErrorIs(t, err, errInvalidArchive)
+	})
+	t.Run("unsupported version should error", func(t *testing.T) {
+		t.Parallel()
+
+		data := createArchive(t, sections...)
+		binary.BigEndian.PutUint32(data[len(archiveMagic):], ArchiveVersion+1)
+		readData, err := readSections(data)
+		assert.Nil(t, readData)
+		assert.ErrorIs(t, err, errUnsupportedArchiveVersion)
+	})
+	t.Run("changed content should fail the checksum", func(t *testing.T) {
+		t.Parallel()
+
+		data := createArchive(t, sections...)
+		index := bytes.Index(data, []byte("v1"))
+		data[index] = 'x'
+		readData, err := readSections(data)
+		assert.Nil(t, readData)
+		assert.Equal(t, errArchiveChecksumMismatch, err)
+	})
+	t.Run("truncated archive should error", func(t *testing.T) {
+		t.Parallel()
+
+		data := createArchive(t, sections...)
+		readData, err := readSections(data[:len(data)-40])
+		assert.Nil(t, readData)
+		assert.ErrorIs(t, err, errInvalidArchive)
+
+		readData, err = readSections(data[:len(data)-1])
+		assert.Nil(t, readData)
+		assert.ErrorIs(t, err, errInvalidArchive)
+	})
+	t.Run("records outside a section should error", func(t *testing.T) {
+		t.Parallel()
+
+		archive, _ := newArchiveWriter(&bytes.Buffer{})
+		err := archive.writeRecord([]byte("a"), []byte("b"))
+		assert.ErrorIs(t, err, errInvalidArchive)
+
+		_ = archive.startSection("A")
+		err = archive.startSection("B")
+		assert.ErrorIs(t, err, errInvalidArchive)
+		err = archive.close()
+		assert.ErrorIs(t, err, errInvalidArchive)
+	})
+}
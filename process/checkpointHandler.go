@@ -0,0 +1,242 @@
+package process
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// CheckpointFileName is the default name of the checkpoint file, created in the destination parent directory
+	CheckpointFileName = ".level-db-copy-checkpoint.json"
+	checkpointFileMode = 0600
+)
+
+type checkpointData struct {
+	Run        map[string]string `json:"run"`
+	Completed  []string          `json:"completed"`
+	InProgress map[string]string `json:"inProgress"`
+}
+
+// ArgsCheckpointHandler is the DTO used to create a new instance of type checkpointHandler
+type ArgsCheckpointHandler struct {
+	FilePath string
+	Resume   bool
+	// RunInfo holds the paths and the options of the run, recorded in the file to refuse resuming a different run
+	RunInfo map[string]string
+}
+
+type checkpointHandler struct {
+	mutData    sync.Mutex
+	filePath   string
+	runInfo    map[string]string
+	completed  map[string]struct{}
+	inProgress map[string][]byte
+}
+
+// NewCheckpointHandler creates a new instance of type checkpointHandler. If the resume flag is set, the progress
+// recorded in the provided file is loaded, otherwise the recording starts from scratch. Errors if the file was
+// written by a run with different paths or options
+func NewCheckpointHandler(args ArgsCheckpointHandler) (*checkpointHandler, error) {
+	filePath := args.FilePath
+	handler := &checkpointHandler{
+		filePath:   filePath,
+		runInfo:    args.RunInfo,
+		completed:  make(map[string]struct{}),
+		inProgress: make(map[string][]byte),
+	}
+	if !args.Resume {
+		return handler, nil
+	}
+
+	buff, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		log.Warn("checkpoint file not found, starting from scratch", "file", filePath)
+		return handler, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &checkpointData{}
+	err = json.Unmarshal(buff, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s for file %s", errInvalidCheckpointFile, err.Error(), filePath)
+	}
+	mismatches := getRunInfoMismatches(data.Run, args.RunInfo)
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("%w: file %s, different %s", errCheckpointRunMismatch, filePath, strings.Join(mismatches, ", "))
+	}
+
+	for name, lastKeyHex := range data.InProgress {
+		handler.inProgress[name], err = hex.DecodeString(lastKeyHex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s for DB %s in file %s", errInvalidCheckpointFile, err.Error(), name, filePath)
+		}
+	}
+	for _, name := range data.Completed {
+		handler.completed[name] = struct{}{}
+	}
+
+	log.Info("resuming from checkpoint", "file", filePath, "completed DBs", len(handler.completed),
+		"DBs in progress", len(handler.inProgress))
+
+	return handler, nil
+}
+
+// getRunInfoMismatches returns the sorted names of the run info entries that differ between the recorded and the
+// current run
+func getRunInfoMismatches(recorded map[string]string, current map[string]string) []string {
+	mismatches := make([]string, 0)
+	for name, value := range current {
+		recordedValue, found := recorded[name]
+		if !found || recordedValue != value {
+			mismatches = append(mismatches, name)
+		}
+	}
+	for name := range recorded {
+		_, found := current[name]
+		if !found {
+			mismatches = append(mismatches, name)
+		}
+	}
+	sort.Strings(mismatches)
+
+	return mismatches
+}
+
+// DefaultCheckpointFilePath returns the default checkpoint file path for the provided destination parent directory
+func DefaultCheckpointFilePath(destParentDir string) string {
+	return filepath.Join(destParentDir, CheckpointFileName)
+}
+
+// IsCompleted returns true if the DB with the provided name was completely processed
+func (handler *checkpointHandler) IsCompleted(name string) bool {
+	handler.mutData.Lock()
+	defer handler.mutData.Unlock()
+
+	_, found := handler.completed[name]
+
+	return found
+}
+
+// LastKey returns the last committed key of the provided DB or nil if the DB was not started
+func (handler *checkpointHandler) LastKey(name string) []byte {
+	handler.mutData.Lock()
+	defer handler.mutData.Unlock()
+
+	return handler.inProgress[name]
+}
+
+// SaveProgress records the last committed key of the provided DB
+func (handler *checkpointHandler) SaveProgress(name string, lastKey []byte) error {
+	handler.mutData.Lock()
+	defer handler.mutData.Unlock()
+
+	handler.inProgress[name] = lastKey
+
+	return handler.save()
+}
+
+// MarkCompleted records that the DB with the provided name was completely processed
+func (handler *checkpointHandler) MarkCompleted(name string) error {
+	handler.mutData.Lock()
+	defer handler.mutData.Unlock()
+
+	handler.completed[name] = struct{}{}
+	delete(handler.inProgress, name)
+
+	return handler.save()
+}
+
+// Clear removes the checkpoint file, called once all the DBs were processed, and forgets the recorded progress
+func (handler *checkpointHandler) Clear() error {
+	handler.mutData.Lock()
+	defer handler.mutData.Unlock()
+
+	handler.completed = make(map[string]struct{})
+	handler.inProgress = make(map[string][]byte)
+
+	err := os.Remove(handler.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (handler *checkpointHandler) save() error {
+	data := &checkpointData{
+		Run:        handler.runInfo,
+		Completed:  make([]string, 0, len(handler.completed)),
+		InProgress: make(map[string]string, len(handler.inProgress)),
+	}
+	for name := range handler.completed {
+		data.Completed = append(data.Completed, name)
+	}
+	sort.Strings(data.Completed)
+	for name, lastKey := range handler.inProgress {
+		data.InProgress[name] = hex.EncodeToString(lastKey)
+	}
+
+	buff, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so an interruption will never leave a truncated checkpoint behind
+	tmpFilePath := handler.filePath + ".tmp"
+	err = os.WriteFile(tmpFilePath, buff, checkpointFileMode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilePath, handler.filePath)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (handler *checkpointHandler) IsInterfaceNil() bool {
+	return handler == nil
+}
+
+type disabledCheckpointHandler struct{}
+
+// NewDisabledCheckpointHandler creates a checkpoint handler that does not record anything
+func NewDisabledCheckpointHandler() *disabledCheckpointHandler {
+	return &disabledCheckpointHandler{}
+}
+
+// IsCompleted returns false
+func (handler *disabledCheckpointHandler) IsCompleted(_ string) bool {
+	return false
+}
+
+// LastKey returns nil
+func (handler *disabledCheckpointHandler) LastKey(_ string) []byte {
+	return nil
+}
+
+// SaveProgress does nothing
+func (handler *disabledCheckpointHandler) SaveProgress(_ string, _ []byte) error {
+	return nil
+}
+
+// MarkCompleted does nothing
+func (handler *disabledCheckpointHandler) MarkCompleted(_ string) error {
+	return nil
+}
+
+// Clear does nothing
+func (handler *disabledCheckpointHandler) Clear() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (handler *disabledCheckpointHandler) IsInterfaceNil() bool {
+	return handler == nil
+}
@@ -0,0 +1,178 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCheckpointArgs(filePath string, resume bool) ArgsCheckpointHandler {
+	return ArgsCheckpointHandler{
+		FilePath: filePath,
+		Resume:   resume,
+		RunInfo: map[string]string{
+			"source":      "src",
+			"destination": "dest",
+		},
+	}
+}
+
+func TestNewCheckpointHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resume with missing file should start from scratch", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewCheckpointHandler(createCheckpointArgs(filepath.Join(t.TempDir(), CheckpointFileName), true))
+		assert.Nil(t, err)
+		assert.False(t, handler.IsCompleted("A"))
+		assert.Nil(t, handler.LastKey("A"))
+	})
+	t.Run("resume with invalid file should error", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		require.Nil(t, os.WriteFile(filePath, []byte("not a json"), checkpointFileMode))
+
+		handler, err := NewCheckpointHandler(createCheckpointArgs(filePath, true))
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidCheckpointFile)
+	})
+	t.Run("resume with invalid last key should error", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		content := `{"run":{"source":"src","destination":"dest"},"inProgress":{"A":"not hex"}}`
+		require.Nil(t, os.WriteFile(filePath, []byte(content), checkpointFileMode))
+
+		handler, err := NewCheckpointHandler(createCheckpointArgs(filePath, true))
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidCheckpointFile)
+	})
+	t.Run("resume a different run should error", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		handler, _ := NewCheckpointHandler(createCheckpointArgs(filePath, false))
+		require.Nil(t, handler.MarkCompleted("A"))
+
+		args := createCheckpointArgs(filePath, true)
+		args.RunInfo["destination"] = "other dest"
+		args.RunInfo["key-prefix"] = "prefix"
+		resumedHandler, err := NewCheckpointHandler(args)
+		assert.Nil(t, resumedHandler)
+		assert.ErrorIs(t, err, errCheckpointRunMismatch)
+		assert.Contains(t, err.Error(), "different destination, key-prefix")
+	})
+	t.Run("resume with a file without run info should error", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		require.Nil(t, os.WriteFile(filePath, []byte(`{"completed":["A"]}`), checkpointFileMode))
+
+		handler, err := NewCheckpointHandler(createCheckpointArgs(filePath, true))
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errCheckpointRunMismatch)
+	})
+	t.Run("without resume should ignore the existing file", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		require.Nil(t, os.WriteFile(filePath, []byte(`{"completed":["A"]}`), checkpointFileMode))
+
+		handler, err := NewCheckpointHandler(createCheckpointArgs(filePath, false))
+		assert.Nil(t, err)
+		assert.False(t, handler.IsCompleted("A"))
+	})
+}
+
+func TestCheckpointHandler_SaveAndResume(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+	handler, _ := NewCheckpointHandler(createCheckpointArgs(filePath, false))
+
+	err := handler.MarkCompleted("B")
+	assert.Nil(t, err)
+	err = handler.SaveProgress("A", []byte("key1"))
+	assert.Nil(t, err)
+	err = handler.SaveProgress("C", []byte("key2"))
+	assert.Nil(t, err)
+
+	assert.True(t, handler.IsCompleted("B"))
+	assert.Equal(t, []byte("key1"), handler.LastKey("A"))
+	assert.Equal(t, []byte("key2"), handler.LastKey("C"))
+
+	resumedHandler, err := NewCheckpointHandler(createCheckpointArgs(filePath, true))
+	assert.Nil(t, err)
+	assert.True(t, resumedHandler.IsCompleted("B"))
+	assert.False(t, resumedHandler.IsCompleted("C"))
+	assert.Equal(t, []byte("key1"), resumedHandler.LastKey("A"))
+	assert.Equal(t, []byte("key2"), resumedHandler.LastKey("C"))
+
+	err = resumedHandler.MarkCompleted("C")
+	assert.Nil(t, err)
+	assert.Nil(t, resumedHandler.LastKey("C"))
+
+	resumedHandler, _ = NewCheckpointHandler(createCheckpointArgs(filePath, true))
+	assert.True(t, resumedHandler.IsCompleted("B"))
+	assert.True(t, resumedHandler.IsCompleted("C"))
+	assert.Nil(t, resumedHandler.LastKey("C"))
+	assert.Equal(t, []byte("key1"), resumedHandler.LastKey("A"))
+}
+
+func TestCheckpointHandler_Clear(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should remove the file and the progress", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), CheckpointFileName)
+		handler, _ := NewCheckpointHandler(createCheckpointArgs(filePath, false))
+		require.Nil(t, handler.MarkCompleted("A"))
+		require.Nil(t, handler.SaveProgress("B", []byte("key1")))
+		require.FileExists(t, filePath)
+
+		err := handler.Clear()
+		assert.Nil(t, err)
+		assert.NoFileExists(t, filePath)
+		assert.False(t, handler.IsCompleted("A"))
+		assert.Nil(t, handler.LastKey("B"))
+	})
+	t.Run("missing file should not error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, _ := NewCheckpointHandler(createCheckpointArgs(filepath.Join(t.TempDir(), CheckpointFileName), false))
+		assert.Nil(t, handler.Clear())
+	})
+}
+
+func TestDisabledCheckpointHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := NewDisabledCheckpointHandler()
+	assert.Nil(t, handler.SaveProgress("A", []byte("key")))
+	assert.Nil(t, handler.MarkCompleted("A"))
+	assert.Nil(t, handler.Clear())
+	assert.False(t, handler.IsCompleted("A"))
+	assert.Nil(t, handler.LastKey("A"))
+}
+
+func TestCheckpointHandler_IsInterfaceNil(t *testing.T) {
+	t.Parallel()
+
+	var instance *checkpointHandler
+	assert.True(t, instance.IsInterfaceNil())
+
+	instance = &checkpointHandler{}
+	assert.False(t, instance.IsInterfaceNil())
+
+	var disabledInstance *disabledCheckpointHandler
+	assert.True(t, disabledInstance.IsInterfaceNil())
+
+	disabledInstance = &disabledCheckpointHandler{}
+	assert.False(t, disabledInstance.IsInterfaceNil())
+}
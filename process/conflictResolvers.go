@@ -0,0 +1,72 @@
+package process
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// KeepDestinationPolicy will leave the destination value untouched when a conflict is found
+	KeepDestinationPolicy = "keep-destination"
+	// OverwriteWithSourcePolicy will replace the destination value with the source value when a conflict is found
+	OverwriteWithSourcePolicy = "overwrite-with-source"
+	// FailPolicy will stop the process when a conflict is found
+	FailPolicy = "fail"
+)
+
+// ConflictPolicies returns all the available built-in conflict policies
+func ConflictPolicies() []string {
+	return []string{KeepDestinationPolicy, OverwriteWithSourcePolicy, FailPolicy}
+}
+
+// NewConflictResolver creates the built-in conflict resolver for the provided policy
+func NewConflictResolver(policy string) (ConflictResolver, error) {
+	switch policy {
+	case KeepDestinationPolicy:
+		return &keepDestinationResolver{}, nil
+	case OverwriteWithSourcePolicy:
+		return &overwriteWithSourceResolver{}, nil
+	case FailPolicy:
+		return &failResolver{}, nil
+	default:
+		return nil, fmt.Errorf("%w %s, available policies: %s",
+			errUnknownConflictPolicy, policy, strings.Join(ConflictPolicies(), ", "))
+	}
+}
+
+type keepDestinationResolver struct{}
+
+// ShouldOverwrite returns false, the destination value is always kept
+func (resolver *keepDestinationResolver) ShouldOverwrite(_ []byte, _ []byte, _ []byte) (bool, error) {
+	return false, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (resolver *keepDestinationResolver) IsInterfaceNil() bool {
+	return resolver == nil
+}
+
+type overwriteWithSourceResolver struct{}
+
+// ShouldOverwrite returns true, the destination value is always replaced by the source value
+func (resolver *overwriteWithSourceResolver) ShouldOverwrite(_ []byte, _ []byte, _ []byte) (bool, error) {
+	return true, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (resolver *overwriteWithSourceResolver) IsInterfaceNil() bool {
+	return resolver == nil
+}
+
+type failResolver struct{}
+
+// ShouldOverwrite returns an error for any conflict
+func (resolver *failResolver) ShouldOverwrite(key []byte, _ []byte, _ []byte) (bool, error) {
+	return false, fmt.Errorf("%w for key %s", errConflictingValuesFound, hex.EncodeToString(key))
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (resolver *failResolver) IsInterfaceNil() bool {
+	return resolver == nil
+}
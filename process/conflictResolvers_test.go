@@ -0,0 +1,80 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConflictResolver(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown policy should error", func(t *testing.T) {
+		t.Parallel()
+
+		resolver, err := NewConflictResolver("unknown")
+		assert.True(t, check.IfNil(resolver))
+		assert.ErrorIs(t, err, errUnknownConflictPolicy)
+		assert.Contains(t, err.Error(), "unknown, available policies: keep-destination, overwrite-with-source, fail")
+	})
+	t.Run("should work for all built-in policies", func(t *testing.T) {
+		t.Parallel()
+
+		for _, policy := range ConflictPolicies() {
+			resolver, err := NewConflictResolver(policy)
+			assert.False(t, check.IfNil(resolver))
+			assert.Nil(t, err)
+		}
+	})
+}
+
+func TestConflictResolvers_ShouldOverwrite(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	srcVal := []byte("src")
+	destVal := []byte("dest")
+
+	t.Run("keep destination", func(t *testing.T) {
+		t.Parallel()
+
+		resolver, _ := NewConflictResolver(KeepDestinationPolicy)
+		shouldOverwrite, err := resolver.ShouldOverwrite(key, srcVal, destVal)
+		assert.False(t, shouldOverwrite)
+		assert.Nil(t, err)
+	})
+	t.Run("overwrite with source", func(t *testing.T) {
+		t.Parallel()
+
+		resolver, _ := NewConflictResolver(OverwriteWithSourcePolicy)
+		shouldOverwrite, err := resolver.ShouldOverwrite(key, srcVal, destVal)
+		assert.True(t, shouldOverwrite)
+		assert.Nil(t, err)
+	})
+	t.Run("fail", func(t *testing.T) {
+		t.Parallel()
+
+		resolver, _ := NewConflictResolver(FailPolicy)
+		shouldOverwrite, err := resolver.ShouldOverwrite(key, srcVal, destVal)
+		assert.False(t, shouldOverwrite)
+		assert.ErrorIs(t, err, errConflictingValuesFound)
+		assert.Contains(t, err.Error(), "for key 6b6579")
+	})
+}
+
+func TestConflictResolvers_IsInterfaceNil(t *testing.T) {
+	t.Parallel()
+
+	var keepDestination *keepDestinationResolver
+	assert.True(t, keepDestination.IsInterfaceNil())
+	assert.False(t, (&keepDestinationResolver{}).IsInterfaceNil())
+
+	var overwriteWithSource *overwriteWithSourceResolver
+	assert.True(t, overwriteWithSource.IsInterfaceNil())
+	assert.False(t, (&overwriteWithSourceResolver{}).IsInterfaceNil())
+
+	var fail *failResolver
+	assert.True(t, fail.IsInterfaceNil())
+	assert.False(t, (&failResolver{}).IsInterfaceNil())
+}
@@ -0,0 +1,28 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// GetCopyStrategy looks up each source key in the destination DB
+	GetCopyStrategy = "get"
+	// MergeJoinCopyStrategy iterates the source and the destination DBs side by side, in key order
+	MergeJoinCopyStrategy = "merge-join"
+)
+
+// CopyStrategies returns all the available copy strategies
+func CopyStrategies() []string {
+	return []string{MergeJoinCopyStrategy, GetCopyStrategy}
+}
+
+func checkCopyStrategy(strategy string) error {
+	for _, availableStrategy := range CopyStrategies() {
+		if strategy == availableStrategy {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, available strategies: %s", errUnknownCopyStrategy, strategy, strings.Join(CopyStrategies(), ", "))
+}
@@ -1,141 +1,1057 @@
 package process
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"path"
 	"strings"
 	"sync"
+	"time"
+
+	"iulianpascalau/level-db-copy-go/common"
 
 	"github.com/multiversx/mx-chain-core-go/core/check"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 var log = logger.GetOrCreate("process")
 
-type paths struct {
-	src  string
-	dest string
+const (
+	maxSampleKeys      = 5
+	checkpointInterval = 10000
+	minNumWorkers      = 1
+	minBatchSize       = 1
+
+	initialLockRetryDelay = time.Second
+	maxLockRetryDelay     = 30 * time.Second
+
+	maxDeletionsPercent = 100
+)
+
+type dbCopyContext struct {
+	name           string
+	pathInfo       paths
+	src            DBWrapper
+	srcs           []DBWrapper
+	srcBatch       *writeBatch
+	targets        []*destTarget
+	numSourceDiffs int
+	numProcessed   int
+}
+
+// destTarget holds the state of one destination DB filled from the source DB
+type destTarget struct {
+	path      string
+	parentDir string
+	create    bool
+	db        DBWrapper
+	batch     *writeBatch
+	result    dbResult
+}
+
+type dbResult struct {
+	dest          string
+	destParentDir string
+	numInserts    int
+	numConflicts  int
+	numOverwrites int
+	numBytes      int
+	sampleKeys    []string
+	created       bool
+	snapshot      *common.SnapshotInfo
+
+	numReverseInserts int
+	numReverseBytes   int
+	numRemovals       int
+}
+
+// ArgsDataCopyHandler is the DTO used to create a new instance of type data copy handler
+type ArgsDataCopyHandler struct {
+	DirectoriesHandler  DirectoriesHandler
+	DBWrapperFactory    DBWrapperFactory
+	NumWorkers          int
+	ConflictResolver    ConflictResolver
+	CheckpointHandler   CheckpointHandler
+	LockChecker         LockChecker
+	WaitForLock         time.Duration
+	BatchSize           int
+	SyncWrites          bool
+	DryRun              bool
+	CreateMissing       bool
+	KeyRange            KeyRange
+	CopyStrategy        string
+	Bidirectional       bool
+	Mirror              bool
+	MirrorConfirmed     bool
+	MaxDeletions        int
+	MaxDeletionsPercent float64
 }
 
 type dataCopyHandler struct {
-	mutCriticalArea    sync.Mutex
-	directoriesHandler DirectoriesHandler
-	srcDBWrapper       DBWrapper
-	destDBWrapper      DBWrapper
+	mutCriticalArea     sync.Mutex
+	directoriesHandler  DirectoriesHandler
+	dbWrapperFactory    DBWrapperFactory
+	numWorkers          int
+	conflictResolver    ConflictResolver
+	checkpointHandler   CheckpointHandler
+	lockChecker         LockChecker
+	waitForLock         time.Duration
+	lockRetryDelay      time.Duration
+	batchSize           int
+	syncWrites          bool
+	dryRun              bool
+	createMissing       bool
+	keyRange            KeyRange
+	copyStrategy        string
+	bidirectional       bool
+	mirror              bool
+	maxDeletions        int
+	maxDeletionsPercent float64
 }
 
 // NewDataCopyHandler creates a new instance of type data copy handler
-func NewDataCopyHandler(
-	directoriesHandler DirectoriesHandler,
-	srcDBWrapper DBWrapper,
-	destDBWrapper DBWrapper,
-) (*dataCopyHandler, error) {
-	if check.IfNil(directoriesHandler) {
+func NewDataCopyHandler(args ArgsDataCopyHandler) (*dataCopyHandler, error) {
+	if check.IfNil(args.DirectoriesHandler) {
 		return nil, errNilDirectoriesHandler
 	}
-	if check.IfNil(srcDBWrapper) {
-		return nil, fmt.Errorf("%w for the source DB wrapper", errNilDBWrapper)
+	if len(args.DirectoriesHandler.DestinationParentDirectories()) == 0 {
+		return nil, errNoDestinationParentDirectory
+	}
+	if check.IfNil(args.DBWrapperFactory) {
+		return nil, errNilDBWrapperFactory
+	}
+	if args.NumWorkers < minNumWorkers {
+		return nil, fmt.Errorf("%w, provided %d, minimum %d", errInvalidNumWorkers, args.NumWorkers, minNumWorkers)
+	}
+	if check.IfNil(args.ConflictResolver) {
+		return nil, errNilConflictResolver
+	}
+	if check.IfNil(args.CheckpointHandler) {
+		return nil, errNilCheckpointHandler
+	}
+	if check.IfNil(args.LockChecker) {
+		return nil, errNilLockChecker
+	}
+	if args.BatchSize < minBatchSize {
+		return nil, fmt.Errorf("%w, provided %d, minimum %d", errInvalidBatchSize, args.BatchSize, minBatchSize)
+	}
+	err := checkCopyStrategy(args.CopyStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if args.Bidirectional && args.CopyStrategy != MergeJoinCopyStrategy {
+		return nil, fmt.Errorf("%w: the bidirectional mode requires the %s copy strategy", errIncompatibleOptions, MergeJoinCopyStrategy)
 	}
-	if check.IfNil(destDBWrapper) {
-		return nil, fmt.Errorf("%w for the destination DB wrapper", errNilDBWrapper)
+	if args.Bidirectional && args.CreateMissing {
+		return nil, fmt.Errorf("%w: the bidirectional mode can not create the missing DBs", errIncompatibleOptions)
+	}
+	if args.Bidirectional && len(args.DirectoriesHandler.SourceParentDirectories()) > 1 {
+		return nil, fmt.Errorf("%w: the bidirectional mode requires a single source parent directory", errIncompatibleOptions)
+	}
+	if args.Bidirectional && len(args.DirectoriesHandler.DestinationParentDirectories()) > 1 {
+		return nil, fmt.Errorf("%w: the bidirectional mode requires a single destination parent directory", errIncompatibleOptions)
+	}
+	err = checkMirrorArgs(args)
+	if err != nil {
+		return nil, err
 	}
 
 	return &dataCopyHandler{
-		directoriesHandler: directoriesHandler,
-		srcDBWrapper:       srcDBWrapper,
-		destDBWrapper:      destDBWrapper,
+		directoriesHandler:  args.DirectoriesHandler,
+		dbWrapperFactory:    args.DBWrapperFactory,
+		numWorkers:          args.NumWorkers,
+		conflictResolver:    args.ConflictResolver,
+		checkpointHandler:   args.CheckpointHandler,
+		lockChecker:         args.LockChecker,
+		waitForLock:         args.WaitForLock,
+		lockRetryDelay:      initialLockRetryDelay,
+		batchSize:           args.BatchSize,
+		syncWrites:          args.SyncWrites,
+		dryRun:              args.DryRun,
+		createMissing:       args.CreateMissing,
+		keyRange:            args.KeyRange,
+		copyStrategy:        args.CopyStrategy,
+		bidirectional:       args.Bidirectional,
+		mirror:              args.Mirror,
+		maxDeletions:        args.MaxDeletions,
+		maxDeletionsPercent: args.MaxDeletionsPercent,
 	}, nil
 }
 
-// Process will attempt to complete the DB copy process
+func checkMirrorArgs(args ArgsDataCopyHandler) error {
+	if args.MaxDeletions < 0 {
+		return fmt.Errorf("%w, maximum deletions %d should not be negative", errInvalidDeletionsCap, args.MaxDeletions)
+	}
+	if args.MaxDeletionsPercent < 0 || args.MaxDeletionsPercent > maxDeletionsPercent {
+		return fmt.Errorf("%w, maximum deletions percent %.2f should be between 0 and %d",
+			errInvalidDeletionsCap, args.MaxDeletionsPercent, maxDeletionsPercent)
+	}
+	if !args.Mirror {
+		return nil
+	}
+	if args.CopyStrategy != MergeJoinCopyStrategy {
+		return fmt.Errorf("%w: the mirror mode requires the %s copy strategy", errIncompatibleOptions, MergeJoinCopyStrategy)
+	}
+	if args.Bidirectional {
+		return fmt.Errorf("%w: the mirror and the bidirectional modes can not be used together", errIncompatibleOptions)
+	}
+	if !args.MirrorConfirmed && !args.DryRun {
+		return errMirrorNotConfirmed
+	}
+
+	return nil
+}
+
+// Process will attempt to complete the DB copy process. In dry-run mode, the DBs are only analysed and nothing
+// is written in the destination DBs. The DBs already completed, as recorded by the checkpoint handler, are skipped.
+// Before opening any DB, all the DBs are checked not to be locked by another process (e.g. a running node).
+// In bidirectional mode, the keys missing from the source DBs are also copied from the destination DBs. In mirror mode,
+// the destination keys missing from the source DBs are removed. Each source DB is read once, filling the matching DBs
+// from all the destination parent directories
 func (handler *dataCopyHandler) Process() error {
 	handler.mutCriticalArea.Lock()
 	defer handler.mutCriticalArea.Unlock()
 
-	commonDirs, names := handler.computeCommonDirs()
-	log.Info("Common directories between the source and destination parent paths", "sub-directories", names)
+	selection, err := handler.computeCommonDirs()
+	if err != nil {
+		return err
+	}
+	logSelection(selection, handler.createMissing)
+	if !handler.keyRange.IsFullRange() {
+		log.Info("processing only the keys from the range", "range", handler.keyRange.String())
+	}
+	if handler.bidirectional {
+		log.Info("bidirectional mode: the missing keys are copied in both directions")
+	}
+	if handler.mirror {
+		log.Info("mirror mode: the destination keys missing from the source are removed",
+			"maximum deletions", handler.maxDeletions, "maximum deletions percent", handler.maxDeletionsPercent)
+	}
 
-	counter := 1
-	for name, pathInfo := range commonDirs {
-		log.Info("now processing sub-directory", "name", name, "overall progress", fmt.Sprintf("%d/%d", counter, len(commonDirs)))
+	sortedNames := selection.names
+	commonDirs := selection.dirs
+	err = handler.waitForUnlockedDBs(sortedNames, commonDirs)
+	if err != nil {
+		return err
+	}
 
-		numInserts, err := handler.processDB(pathInfo)
+	results := make([][]dbResult, len(sortedNames))
+	jobs := make(chan int)
+	errHandler := &firstErrorHolder{}
+	wg := sync.WaitGroup{}
+	wg.Add(handler.numWorkers)
+	for i := 0; i < handler.numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for index := range jobs {
+				if errHandler.get() != nil {
+					continue
+				}
+
+				name := sortedNames[index]
+				dbResults, err := handler.processDB(name, commonDirs[name])
+				if err != nil {
+					errHandler.set(err)
+					continue
+				}
+
+				results[index] = dbResults
+				for _, result := range dbResults {
+					handler.logResult(name, result)
+				}
+			}
+		}()
+	}
+
+	for index, name := range sortedNames {
+		if errHandler.get() != nil {
+			break
+		}
+
+		progress := fmt.Sprintf("%d/%d", index+1, len(sortedNames))
+		if handler.checkpointHandler.IsCompleted(name) {
+			log.Info("skipping sub-directory, already completed", "name", name, "overall progress", progress)
+			continue
+		}
+
+		log.Info("now processing sub-directory", "name", name, "overall progress", progress)
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	handler.logSummary(sortedNames, results)
+
+	err = errHandler.get()
+	if err != nil || handler.dryRun {
+		return err
+	}
+
+	// all the DBs were processed, a later run must not skip them
+	return handler.checkpointHandler.Clear()
+}
+
+func (handler *dataCopyHandler) waitForUnlockedDBs(sortedNames []string, commonDirs map[string]paths) error {
+	deadline := time.Now().Add(handler.waitForLock)
+	delay := handler.lockRetryDelay
+	for {
+		lockedPaths, err := handler.getLockedPaths(sortedNames, commonDirs)
 		if err != nil {
 			return err
 		}
+		if len(lockedPaths) == 0 {
+			return nil
+		}
+
+		errLocked := fmt.Errorf("%w: %s", errLockedDBs, strings.Join(lockedPaths, ", "))
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return errLocked
+		}
+		if delay > remaining {
+			delay = remaining
+		}
 
-		log.Info("successfully processed DB", "name", name, "missing info added", numInserts)
-		counter++
+		log.Warn("waiting for the DBs to be released", "locked DBs", strings.Join(lockedPaths, ", "),
+			"retry in", delay, "time left", remaining)
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxLockRetryDelay {
+			delay = maxLockRetryDelay
+		}
 	}
+}
 
-	return nil
+func (handler *dataCopyHandler) getLockedPaths(sortedNames []string, commonDirs map[string]paths) ([]string, error) {
+	lockedPaths := make([]string, 0)
+	for _, name := range sortedNames {
+		if handler.checkpointHandler.IsCompleted(name) {
+			continue
+		}
+
+		pathInfo := commonDirs[name]
+		dbPaths := pathInfo.allSrcs()
+		for _, dest := range pathInfo.allDests() {
+			dbPaths = append(dbPaths, dest.path)
+		}
+		for _, dbPath := range dbPaths {
+			isLocked, err := handler.lockChecker.IsLocked(dbPath)
+			if err != nil {
+				return nil, fmt.Errorf("%w while checking the lock for path %s", err, dbPath)
+			}
+			if isLocked {
+				lockedPaths = append(lockedPaths, dbPath)
+			}
+		}
+	}
+
+	return lockedPaths, nil
+}
+
+func (handler *dataCopyHandler) logResult(name string, result dbResult) {
+	if handler.bidirectional {
+		log.Info("synchronized DBs", "name", name, "dest path", result.dest, "info added to destination", result.numInserts,
+			"info added to source", result.numReverseInserts, "conflicts", result.numConflicts,
+			"overwritten", result.numOverwrites, "bytes", result.numBytes+result.numReverseBytes, "dry run", handler.dryRun)
+		return
+	}
+	if handler.dryRun {
+		log.Info("dry run: analysed DB", "name", name, "dest path", result.dest, "missing info to add", result.numInserts,
+			"conflicts", result.numConflicts, "values to overwrite", result.numOverwrites,
+			"bytes to write", result.numBytes, "sample keys", strings.Join(result.sampleKeys, ", "), "create", result.created,
+			"keys to remove", result.numRemovals, "source snapshot", result.snapshot.String())
+		return
+	}
+	if result.created {
+		log.Info("successfully created DB", "name", name, "dest path", result.dest, "info added", result.numInserts, "bytes written", result.numBytes,
+			"source snapshot", result.snapshot.String())
+		return
+	}
+
+	if handler.mirror {
+		log.Info("successfully mirrored DB", "name", name, "dest path", result.dest, "missing info added", result.numInserts,
+			"conflicts", result.numConflicts, "overwritten", result.numOverwrites, "removed", result.numRemovals,
+			"bytes written", result.numBytes, "source snapshot", result.snapshot.String())
+		return
+	}
+
+	log.Info("successfully processed DB", "name", name, "dest path", result.dest, "missing info added", result.numInserts,
+		"conflicts", result.numConflicts, "overwritten", result.numOverwrites, "bytes written", result.numBytes,
+		"source snapshot", result.snapshot.String())
 }
 
-func (handler *dataCopyHandler) computeCommonDirs() (map[string]paths, string) {
-	srcDirs := convertDirStrings(handler.directoriesHandler.SourceDirectories())
-	destDirs := convertDirStrings(handler.directoriesHandler.DestinationDirectories())
+func (handler *dataCopyHandler) logSummary(sortedNames []string, results [][]dbResult) {
+	total := &destTotals{}
+	destParentDirs := handler.directoriesHandler.DestinationParentDirectories()
+	parentTotals := make(map[string]*destTotals, len(destParentDirs))
+	for _, destParentDir := range destParentDirs {
+		parentTotals[destParentDir] = &destTotals{}
+	}
+
+	numProcessed := 0
+	for index, dbResults := range results {
+		if dbResults == nil {
+			continue
+		}
 
-	commonDirs := make(map[string]paths, len(srcDirs)+len(destDirs))
-	names := make([]string, 0, len(srcDirs)+len(destDirs))
-	for name, srcFullPath := range srcDirs {
-		destFullPath, found := destDirs[name]
-		if found {
-			commonDirs[name] = paths{
-				src:  srcFullPath,
-				dest: destFullPath,
+		numProcessed++
+		for _, result := range dbResults {
+			handler.logDBSummary(sortedNames[index], result)
+			total.add(result)
+			parentTotal, found := parentTotals[result.destParentDir]
+			if found {
+				parentTotal.add(result)
 			}
-			names = append(names, name)
 		}
 	}
 
-	return commonDirs, strings.Join(names, ", ")
+	if len(destParentDirs) > 1 {
+		for _, destParentDir := range destParentDirs {
+			parentTotal := parentTotals[destParentDir]
+			log.Info("summary", "destination", destParentDir, "processed DBs", parentTotal.numDBs,
+				"created DBs", parentTotal.numCreated, "missing info added", parentTotal.numInserts,
+				"conflicts", parentTotal.numConflicts, "overwritten", parentTotal.numOverwrites,
+				"removed", parentTotal.numRemovals, "bytes", parentTotal.numBytes)
+		}
+	}
+
+	if handler.bidirectional {
+		log.Info("summary", "processed DBs", fmt.Sprintf("%d/%d", numProcessed, len(sortedNames)),
+			"info added to destination", total.numInserts, "info added to source", total.numReverseInserts,
+			"conflicts", total.numConflicts, "overwritten", total.numOverwrites,
+			"bytes", total.numBytes+total.numReverseBytes, "dry run", handler.dryRun)
+		return
+	}
+
+	log.Info("summary", "processed DBs", fmt.Sprintf("%d/%d", numProcessed, len(sortedNames)), "created DBs", total.numCreated,
+		"missing info added", total.numInserts, "conflicts", total.numConflicts,
+		"overwritten", total.numOverwrites, "removed", total.numRemovals, "bytes", total.numBytes, "dry run", handler.dryRun)
 }
 
-func convertDirStrings(dirStrings []string) map[string]string {
-	mapDirs := make(map[string]string, len(dirStrings))
-	for _, dir := range dirStrings {
-		_, lastDirElement := path.Split(dir)
-		mapDirs[lastDirElement] = dir
+func (handler *dataCopyHandler) logDBSummary(name string, result dbResult) {
+	if handler.bidirectional {
+		log.Info("summary", "name", name, "info added to destination", result.numInserts,
+			"info added to source", result.numReverseInserts, "conflicts", result.numConflicts,
+			"overwritten", result.numOverwrites, "bytes", result.numBytes+result.numReverseBytes)
+		return
 	}
 
-	return mapDirs
+	log.Info("summary", "name", name, "dest path", result.dest, "missing info added", result.numInserts,
+		"conflicts", result.numConflicts, "overwritten", result.numOverwrites, "bytes", result.numBytes,
+		"created", result.created, "removed", result.numRemovals, "source snapshot", result.snapshot.String())
+}
+
+// destTotals accumulates the results of the DBs written in a destination parent directory, or in all of them
+type destTotals struct {
+	dbResult
+	numDBs     int
+	numCreated int
 }
 
-func (handler *dataCopyHandler) processDB(pathInfo paths) (int, error) {
-	err := handler.srcDBWrapper.Open(pathInfo.src)
+func (totals *destTotals) add(result dbResult) {
+	totals.numDBs++
+	if result.created {
+		totals.numCreated++
+	}
+	totals.numInserts += result.numInserts
+	totals.numConflicts += result.numConflicts
+	totals.numOverwrites += result.numOverwrites
+	totals.numBytes += result.numBytes
+	totals.numReverseInserts += result.numReverseInserts
+	totals.numReverseBytes += result.numReverseBytes
+	totals.numRemovals += result.numRemovals
+}
+
+func (handler *dataCopyHandler) computeCommonDirs() (*dirsSelection, error) {
+	return selectDirectories(handler.directoriesHandler, handler.createMissing)
+}
+
+func (handler *dataCopyHandler) processDB(name string, pathInfo paths) ([]dbResult, error) {
+	srcDBWrapper, err := handler.createSrcDBWrapper(pathInfo)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	targets, err := handler.createTargets(pathInfo)
+	if err != nil {
+		return nil, err
 	}
 
-	err = handler.destDBWrapper.Open(pathInfo.dest)
+	err = srcDBWrapper.Open(pathInfo.src)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	snapshot := srcDBWrapper.SnapshotInfo()
+	log.Info("reading the source DB", "name", name, "path", pathInfo.src, "snapshot", snapshot.String())
 
-	numInserts := 0
-	handlerFunc := func(key []byte, val []byte) bool {
-		existingValue, _ := handler.destDBWrapper.Get(key)
-		if existingValue == nil {
-			err = handler.destDBWrapper.Put(key, val)
-			if err != nil {
-				log.Error("error encountered while processing a DB put operation",
-					"dest path", pathInfo.dest, "key", key)
-			} else {
-				numInserts++
+	extraSrcDBWrappers, err := openExtraSources(handler.dbWrapperFactory, name, pathInfo)
+	if err != nil {
+		_ = srcDBWrapper.Close()
+		return nil, err
+	}
+	srcDBWrappers := append([]DBWrapper{srcDBWrapper}, extraSrcDBWrappers...)
+
+	err = handler.openTargets(targets)
+	if err != nil {
+		_ = closeAll(srcDBWrappers)
+		return nil, err
+	}
+
+	dbCtx := &dbCopyContext{
+		name:     name,
+		pathInfo: pathInfo,
+		src:      srcDBWrapper,
+		srcs:     srcDBWrappers,
+		srcBatch: &writeBatch{},
+		targets:  targets,
+	}
+	for _, target := range targets {
+		target.result.snapshot = snapshot
+	}
+
+	lastKey := handler.checkpointHandler.LastKey(name)
+	if lastKey != nil {
+		log.Info("resuming sub-directory after the last committed key", "name", name, "key", lastKey)
+	}
+
+	errProcess := handler.copyKeys(dbCtx, lastKey)
+	if errProcess == nil {
+		errProcess = handler.flush(dbCtx)
+	}
+	if len(extraSrcDBWrappers) > 0 {
+		log.Info("merged the source DBs", "name", name, "sources", len(srcDBWrappers),
+			"keys with different source values", dbCtx.numSourceDiffs)
+	}
+
+	errClose1 := closeAll(srcDBWrappers)
+	errClose2 := closeTargets(targets)
+
+	results := dbCtx.results()
+	if errProcess != nil {
+		return results, errProcess
+	}
+	if errClose1 != nil {
+		return results, errClose1
+	}
+	if errClose2 != nil {
+		return results, errClose2
+	}
+	if handler.dryRun {
+		return results, nil
+	}
+
+	return results, handler.checkpointHandler.MarkCompleted(name)
+}
+
+func (dbCtx *dbCopyContext) results() []dbResult {
+	results := make([]dbResult, 0, len(dbCtx.targets))
+	for _, target := range dbCtx.targets {
+		results = append(results, target.result)
+	}
+
+	return results
+}
+
+// copyKeys iterates the source keys from the configured key range, after the last committed key, if any. A DB
+// that is about to be created is empty so its keys are copied without any lookup. When the DB is read from several
+// sources, their keys are merged and the value from the source with the highest priority is used. Each source key
+// is handled for all the destination DBs before moving to the next one
+func (handler *dataCopyHandler) copyKeys(dbCtx *dbCopyContext, lastKey []byte) error {
+	startKey := handler.keyRange.Start
+	if bytes.Compare(lastKey, startKey) > 0 {
+		startKey = lastKey
+	}
+
+	srcIterator, err := handler.newSourcesIterator(dbCtx, startKey)
+	if err != nil {
+		return err
+	}
+	defer srcIterator.Release()
+
+	if handler.copyStrategy == MergeJoinCopyStrategy && !dbCtx.allTargetsCreated() {
+		return handler.copyKeysMergeJoin(dbCtx, srcIterator, startKey, lastKey)
+	}
+
+	for srcIterator.Next() {
+		key := srcIterator.Key()
+		if lastKey != nil && bytes.Equal(key, lastKey) {
+			continue
+		}
+
+		err = handler.processKey(dbCtx, key, srcIterator.Value())
+		if err != nil {
+			return err
+		}
+	}
+
+	return srcIterator.Error()
+}
+
+func (dbCtx *dbCopyContext) allTargetsCreated() bool {
+	for _, target := range dbCtx.targets {
+		if !target.create {
+			return false
+		}
+	}
+
+	return true
+}
+
+// copyKeysMergeJoin walks the source and the destination keys side by side, in key order, so the missing and the
+// conflicting keys are found with sequential reads only. The iterators work on snapshots so the batches written
+// meanwhile do not change them. In bidirectional mode, the keys found only in the destination are copied in the source
+// and in mirror mode they are removed, after checking the deletions cap
+func (handler *dataCopyHandler) copyKeysMergeJoin(dbCtx *dbCopyContext, srcIterator common.DBIterator, startKey []byte, lastKey []byte) error {
+	if handler.mirror {
+		err := handler.checkDeletionsCap(dbCtx, startKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	destIterators, err := handler.newTargetsIterators(dbCtx, startKey)
+	if err != nil {
+		return err
+	}
+	defer releaseAll(destIterators)
+
+	var errProcess error
+	err = walkMergedFanOut(srcIterator, destIterators, func(entries []*mergedEntry) bool {
+		key := entries[0].key
+		if lastKey != nil && bytes.Equal(key, lastKey) {
+			return true
+		}
+		if !entries[0].inSrc && !handler.bidirectional && !handler.mirror {
+			return true
+		}
+
+		for index, entry := range entries {
+			errProcess = handler.processEntry(dbCtx, dbCtx.targets[index], entry)
+			if errProcess != nil {
+				return false
+			}
+		}
+
+		errProcess = handler.keyProcessed(dbCtx, key)
+		return errProcess == nil
+	})
+	if errProcess != nil {
+		return errProcess
+	}
+
+	return err
+}
+
+// processEntry handles a merged entry for one destination DB
+func (handler *dataCopyHandler) processEntry(dbCtx *dbCopyContext, target *destTarget, entry *mergedEntry) error {
+	if !entry.inSrc {
+		if !entry.inDest {
+			return nil
+		}
+		if handler.bidirectional {
+			handler.processReverseValue(dbCtx, target, entry.key, entry.destValue)
+		}
+		if handler.mirror {
+			handler.processRemoval(target, entry.key)
+		}
+		return nil
+	}
+
+	var existingValue []byte
+	if entry.inDest {
+		existingValue = entry.destValue
+	}
+
+	return handler.resolveValues(target, entry.key, entry.srcValue, existingValue)
+}
+
+// newTargetsIterators returns an iterator for each destination DB. A DB that is about to be created is empty
+func (handler *dataCopyHandler) newTargetsIterators(dbCtx *dbCopyContext, startKey []byte) ([]common.DBIterator, error) {
+	iterators := make([]common.DBIterator, 0, len(dbCtx.targets))
+	for _, target := range dbCtx.targets {
+		if target.create {
+			iterators = append(iterators, newEmptyDBIterator(nil))
+			continue
+		}
+
+		iterator, err := target.db.NewIterator(startKey, handler.keyRange.End)
+		if err != nil {
+			releaseAll(iterators)
+			return nil, err
+		}
+		iterators = append(iterators, iterator)
+	}
+
+	return iterators, nil
+}
+
+func releaseAll(iterators []common.DBIterator) {
+	for _, iterator := range iterators {
+		iterator.Release()
+	}
+}
+
+// checkDeletionsCap counts, before any removal, the destination keys missing from the source and errors if the
+// configured maximum number or percentage of deletions is exceeded in any of the destination DBs
+func (handler *dataCopyHandler) checkDeletionsCap(dbCtx *dbCopyContext, startKey []byte) error {
+	srcIterator, err := newSourcesIterator(dbCtx.srcs, startKey, handler.keyRange.End, nil)
+	if err != nil {
+		return err
+	}
+	defer srcIterator.Release()
+
+	destIterators, err := handler.newTargetsIterators(dbCtx, startKey)
+	if err != nil {
+		return err
+	}
+	defer releaseAll(destIterators)
+
+	numDestKeys := make([]int, len(destIterators))
+	numDeletions := make([]int, len(destIterators))
+	err = walkMergedFanOut(srcIterator, destIterators, func(entries []*mergedEntry) bool {
+		for index, entry := range entries {
+			if !entry.inDest {
+				continue
+			}
+			numDestKeys[index]++
+			if !entry.inSrc {
+				numDeletions[index]++
 			}
 		}
 
 		return true
+	})
+	if err != nil {
+		return err
 	}
 
-	handler.srcDBWrapper.RangeKeys(handlerFunc)
+	for index, target := range dbCtx.targets {
+		err = handler.checkTargetDeletionsCap(target.path, numDeletions[index], numDestKeys[index])
+		if err != nil {
+			return err
+		}
+	}
 
-	errClose1 := handler.srcDBWrapper.Close()
-	errClose2 := handler.destDBWrapper.Close()
+	return nil
+}
 
-	if errClose1 != nil {
-		return numInserts, errClose1
+func (handler *dataCopyHandler) checkTargetDeletionsCap(destPath string, numDeletions int, numDestKeys int) error {
+	if handler.maxDeletions > 0 && numDeletions > handler.maxDeletions {
+		return fmt.Errorf("%w, dest path %s: %d keys to remove, maximum %d",
+			errDeletionsCapExceeded, destPath, numDeletions, handler.maxDeletions)
+	}
+	percent := 0.0
+	if numDestKeys > 0 {
+		percent = float64(numDeletions) * 100 / float64(numDestKeys)
+	}
+	if handler.maxDeletionsPercent > 0 && percent > handler.maxDeletionsPercent {
+		return fmt.Errorf("%w, dest path %s: %d keys to remove out of %d (%.2f%%), maximum %.2f%%",
+			errDeletionsCapExceeded, destPath, numDeletions, numDestKeys, percent, handler.maxDeletionsPercent)
+	}
+
+	return nil
+}
+
+func (handler *dataCopyHandler) newSourcesIterator(dbCtx *dbCopyContext, startKey []byte) (common.DBIterator, error) {
+	return newSourcesIterator(dbCtx.srcs, startKey, handler.keyRange.End, func(key []byte) {
+		dbCtx.numSourceDiffs++
+		log.Trace("different source values found, using the one with the highest priority", "name", dbCtx.name, "key", key)
+	})
+}
+
+// openExtraSources opens, in read-only mode, the DB from the lower priority source parent directories
+func openExtraSources(factory DBWrapperFactory, name string, pathInfo paths) ([]DBWrapper, error) {
+	wrappers := make([]DBWrapper, 0, len(pathInfo.extraSrcs))
+	for _, srcPath := range pathInfo.extraSrcs {
+		wrapper, err := openReadOnly(factory, srcPath)
+		if err != nil {
+			_ = closeAll(wrappers)
+			return nil, err
+		}
+
+		log.Info("reading the source DB", "name", name, "path", srcPath, "snapshot", wrapper.SnapshotInfo().String())
+		wrappers = append(wrappers, wrapper)
+	}
+
+	return wrappers, nil
+}
+
+// closeAll closes all the provided DB wrappers, returning the first error, if any
+func closeAll(wrappers []DBWrapper) error {
+	var firstErr error
+	for _, wrapper := range wrappers {
+		err := wrapper.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// createSrcDBWrapper returns the source DB wrapper, writable only in bidirectional mode, outside dry-run mode
+func (handler *dataCopyHandler) createSrcDBWrapper(pathInfo paths) (DBWrapper, error) {
+	if handler.bidirectional && !handler.dryRun {
+		return handler.dbWrapperFactory.Create(pathInfo.src, WritableSourceRole)
+	}
+
+	return handler.dbWrapperFactory.Create(pathInfo.src, SourceRole)
+}
+
+// createTargets returns, not yet opened, the destination DBs from all the destination parent directories
+func (handler *dataCopyHandler) createTargets(pathInfo paths) ([]*destTarget, error) {
+	dests := pathInfo.allDests()
+	targets := make([]*destTarget, 0, len(dests))
+	for _, dest := range dests {
+		wrapper, err := handler.createDestDBWrapper(dest)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, &destTarget{
+			path:      dest.path,
+			parentDir: dest.parentDir,
+			create:    dest.create,
+			db:        wrapper,
+			batch:     &writeBatch{},
+			result: dbResult{
+				dest:          dest.path,
+				destParentDir: dest.parentDir,
+				created:       dest.create,
+			},
+		})
+	}
+
+	return targets, nil
+}
+
+// createDestDBWrapper returns the destination DB wrapper. In dry-run mode, the existing DBs are opened in read-only
+// mode and an empty DB is used instead of the DBs about to be created, so no destination file is touched
+func (handler *dataCopyHandler) createDestDBWrapper(dest destPath) (DBWrapper, error) {
+	if !handler.dryRun {
+		return handler.dbWrapperFactory.Create(dest.path, DestinationRole)
+	}
+	if dest.create {
+		return newDisabledDBWrapper(), nil
+	}
+
+	return handler.dbWrapperFactory.Create(dest.path, SourceRole)
+}
+
+// openTargets opens all the destination DBs, closing the already opened ones on error. In dry-run mode, an empty DB
+// is used instead of the destination directories that are not level DBs, as the copy would create them
+func (handler *dataCopyHandler) openTargets(targets []*destTarget) error {
+	for index, target := range targets {
+		err := target.db.Open(target.path)
+		if handler.dryRun && errors.Is(err, errNotALevelDB) {
+			target.db = newDisabledDBWrapper()
+			err = target.db.Open(target.path)
+		}
+		if err != nil {
+			_ = closeTargets(targets[:index])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closeTargets closes all the destination DBs, returning the first error, if any
+func closeTargets(targets []*destTarget) error {
+	wrappers := make([]DBWrapper, 0, len(targets))
+	for _, target := range targets {
+		wrappers = append(wrappers, target.db)
 	}
 
-	return numInserts, errClose2
+	return closeAll(wrappers)
+}
+
+// commit writes the pending batches and, only after that, records the progress in the checkpoint
+func (handler *dataCopyHandler) commit(dbCtx *dbCopyContext, lastKey []byte) error {
+	err := handler.flush(dbCtx)
+	if err != nil {
+		return err
+	}
+	if handler.dryRun {
+		return nil
+	}
+
+	return handler.checkpointHandler.SaveProgress(dbCtx.name, lastKey)
+}
+
+func (handler *dataCopyHandler) flush(dbCtx *dbCopyContext) error {
+	for _, target := range dbCtx.targets {
+		err := handler.flushBatch(target.db, target.batch, target.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.flushBatch(dbCtx.src, dbCtx.srcBatch, dbCtx.pathInfo.src)
+}
+
+func (handler *dataCopyHandler) flushBatch(db DBWrapper, batch *writeBatch, dbPath string) error {
+	if handler.dryRun || batch.len() == 0 {
+		return nil
+	}
+
+	err := db.WriteBatch(batch.keys, batch.values, batch.removedKeys, handler.syncWrites)
+	if err != nil {
+		return fmt.Errorf("%w while writing a batch of %d entries, path %s", err, batch.len(), dbPath)
+	}
+	batch.reset()
+
+	return nil
+}
+
+// processKey looks up the source key in each destination DB, unless the destination DB is about to be created.
+// Only the not found error means the key is missing, any other lookup error stops the copy
+func (handler *dataCopyHandler) processKey(dbCtx *dbCopyContext, key []byte, val []byte) error {
+	for _, target := range dbCtx.targets {
+		var existingValue []byte
+		if !target.create {
+			value, err := target.db.Get(key)
+			if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+				return fmt.Errorf("%w, dest path %s", err, target.path)
+			}
+			existingValue = value
+		}
+
+		err := handler.resolveValues(target, key, val, existingValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.keyProcessed(dbCtx, key)
+}
+
+// processReverseValue copies in the source DB a key found only in the destination DB (bidirectional mode)
+func (handler *dataCopyHandler) processReverseValue(dbCtx *dbCopyContext, target *destTarget, key []byte, val []byte) {
+	if !handler.dryRun {
+		dbCtx.srcBatch.put(key, val)
+	}
+	target.result.numReverseInserts++
+	target.result.numReverseBytes += len(key) + len(val)
+}
+
+// processRemoval removes from the destination DB a key missing from the source DB (mirror mode)
+func (handler *dataCopyHandler) processRemoval(target *destTarget, key []byte) {
+	if !handler.dryRun {
+		target.batch.remove(key)
+	}
+	target.result.numRemovals++
+}
+
+// keyProcessed commits the pending batches when any of them is full or when the checkpoint interval is reached
+func (handler *dataCopyHandler) keyProcessed(dbCtx *dbCopyContext, key []byte) error {
+	dbCtx.numProcessed++
+	isBatchFull := dbCtx.srcBatch.len() >= handler.batchSize
+	for _, target := range dbCtx.targets {
+		isBatchFull = isBatchFull || target.batch.len() >= handler.batchSize
+	}
+	if isBatchFull {
+		return handler.commit(dbCtx, key)
+	}
+	if dbCtx.numProcessed%checkpointInterval == 0 {
+		handler.logProgress(dbCtx)
+		return handler.commit(dbCtx, key)
+	}
+
+	return nil
+}
+
+func (handler *dataCopyHandler) logProgress(dbCtx *dbCopyContext) {
+	for _, target := range dbCtx.targets {
+		log.Info("copy progress", "name", dbCtx.name, "dest path", target.path, "processed keys", dbCtx.numProcessed,
+			"missing info added", target.result.numInserts, "overwritten", target.result.numOverwrites,
+			"removed", target.result.numRemovals)
+	}
+}
+
+func (handler *dataCopyHandler) resolveValues(target *destTarget, key []byte, val []byte, existingValue []byte) error {
+	if existingValue == nil {
+		handler.put(target, key, val)
+		target.result.numInserts++
+		return nil
+	}
+	if bytes.Equal(existingValue, val) {
+		return nil
+	}
+
+	target.result.numConflicts++
+	shouldOverwrite, err := handler.conflictResolver.ShouldOverwrite(key, val, existingValue)
+	if err != nil {
+		return fmt.Errorf("%w, dest path %s", err, target.path)
+	}
+
+	log.Debug("conflicting values found", "dest path", target.path, "key", key, "overwrite", shouldOverwrite)
+	if shouldOverwrite {
+		handler.put(target, key, val)
+		target.result.numOverwrites++
+	}
+
+	return nil
+}
+
+func (handler *dataCopyHandler) put(target *destTarget, key []byte, val []byte) {
+	if !handler.dryRun {
+		target.batch.put(key, val)
+	}
+
+	result := &target.result
+	result.numBytes += len(key) + len(val)
+	if len(result.sampleKeys) < maxSampleKeys {
+		result.sampleKeys = append(result.sampleKeys, hex.EncodeToString(key))
+	}
+}
+
+type firstErrorHolder struct {
+	mut sync.RWMutex
+	err error
+}
+
+func (holder *firstErrorHolder) set(err error) {
+	holder.mut.Lock()
+	defer holder.mut.Unlock()
+
+	if holder.err == nil {
+		holder.err = err
+	}
+}
+
+func (holder *firstErrorHolder) get() error {
+	holder.mut.RLock()
+	defer holder.mut.RUnlock()
+
+	return holder.err
+}
+
+// writeBatch holds the pending writes and removals of a DB, written together in a single atomic write
+type writeBatch struct {
+	keys        [][]byte
+	values      [][]byte
+	removedKeys [][]byte
+}
+
+func (batch *writeBatch) put(key []byte, val []byte) {
+	batch.keys = append(batch.keys, key)
+	batch.values = append(batch.values, val)
+}
+
+func (batch *writeBatch) remove(key []byte) {
+	batch.removedKeys = append(batch.removedKeys, key)
+}
+
+func (batch *writeBatch) len() int {
+	return len(batch.keys) + len(batch.removedKeys)
+}
+
+func (batch *writeBatch) reset() {
+	batch.keys = batch.keys[:0]
+	batch.values = batch.values[:0]
+	batch.removedKeys = batch.removedKeys[:0]
 }
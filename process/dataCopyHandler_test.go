@@ -1,15 +1,25 @@
 package process
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"iulianpascalau/level-db-copy-go/common"
 	"iulianpascalau/level-db-copy-go/testcommon"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 type recorder struct {
+	mut           sync.Mutex
 	srcOpenedDBs  []string
 	srcClosedDBs  []string
 	destOpenedDBs []string
@@ -21,126 +31,1783 @@ type testHandler struct {
 	getOps map[string]string
 }
 
+func createMockArgsDataCopyHandler() ArgsDataCopyHandler {
+	return ArgsDataCopyHandler{
+		DirectoriesHandler: &testcommon.DirectoriesHandlerStub{},
+		DBWrapperFactory:   &dbWrapperFactoryStub{},
+		NumWorkers:         1,
+		ConflictResolver:   &testcommon.ConflictResolverStub{},
+		CheckpointHandler:  &testcommon.CheckpointHandlerStub{},
+		LockChecker:        &testcommon.LockCheckerStub{},
+		BatchSize:          1,
+		CopyStrategy:       GetCopyStrategy,
+	}
+}
+
 func TestNewDataCopyHandler(t *testing.T) {
 	t.Parallel()
 
 	t.Run("nil directories handler should error", func(t *testing.T) {
 		t.Parallel()
 
-		handler, err := NewDataCopyHandler(
-			nil,
-			&testcommon.DBWrapperStub{},
-			&testcommon.DBWrapperStub{},
-		)
+		args := createMockArgsDataCopyHandler()
+		args.DirectoriesHandler = nil
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDirectoriesHandler, err)
+	})
+	t.Run("no destination parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			DestinationParentDirectoriesCalled: func() []string {
+				return make([]string, 0)
+			},
+		}
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNoDestinationParentDirectory, err)
+	})
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.DBWrapperFactory = nil
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("invalid number of workers should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.NumWorkers = 0
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidNumWorkers)
+		assert.Contains(t, err.Error(), "provided 0, minimum 1")
+	})
+	t.Run("nil conflict resolver should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.ConflictResolver = nil
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilConflictResolver, err)
+	})
+	t.Run("nil lock checker should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.LockChecker = nil
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilLockChecker, err)
+	})
+	t.Run("invalid batch size should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.BatchSize = 0
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidBatchSize)
+		assert.Contains(t, err.Error(), "provided 0, minimum 1")
+	})
+	t.Run("nil checkpoint handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.CheckpointHandler = nil
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilCheckpointHandler, err)
+	})
+	t.Run("unknown copy strategy should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = "unknown"
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownCopyStrategy)
+	})
+	t.Run("bidirectional mode with the get copy strategy should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.Bidirectional = true
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("negative maximum deletions should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.MaxDeletions = -1
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidDeletionsCap)
+	})
+	t.Run("invalid maximum deletions percent should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.MaxDeletionsPercent = 100.1
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidDeletionsCap)
+	})
+	t.Run("mirror mode with the get copy strategy should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("unconfirmed mirror mode should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errMirrorNotConfirmed, err)
+
+		args.DryRun = true
+		handler, err = NewDataCopyHandler(args)
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+	t.Run("mirror mode with the bidirectional mode should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		args.Bidirectional = true
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("bidirectional mode with create missing should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Bidirectional = true
+		args.CreateMissing = true
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDataCopyHandler(createMockArgsDataCopyHandler())
+
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+}
+
+func TestDataCopyHandler_Process(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should work", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-1": "dest",
+				"A-key-4": "dest",
+
+				"B-key-1": "dest",
+				"B-key-2": "dest",
+				"B-key-3": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedDBOperationOrder := []string{"A", "B"}
+		expectedPutOperations := map[string]string{
+			"A-key-2": "A-val-s-2",
+			"A-key-3": "A-val-s-3",
+			"B-key-0": "B-val-s-0",
+			"B-key-4": "B-val-s-4",
+		}
+
+		handler, _ := NewDataCopyHandler(setupForProcess(t, test, rec))
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		assert.ElementsMatch(t, expectedDBOperationOrder, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, expectedDBOperationOrder, rec.srcClosedDBs)
+		assert.ElementsMatch(t, expectedDBOperationOrder, rec.destOpenedDBs)
+		assert.ElementsMatch(t, expectedDBOperationOrder, rec.destClosedDBs)
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+	})
+	t.Run("merge-join strategy should find the missing keys without lookups", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-1": "dest",
+				"A-key-4": "dest",
+				"A-key-5": "dest",
+
+				"B-key-1": "dest",
+				"B-key-2": "dest",
+				"B-key-3": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedPutOperations := map[string]string{
+			"A-key-2": "A-val-s-2",
+			"A-key-3": "A-val-s-3",
+			"B-key-0": "B-val-s-0",
+			"B-key-4": "B-val-s-4",
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.GetCalled = func(key []byte) ([]byte, error) {
+				assert.Fail(t, "should have not called Get with the merge-join strategy")
+				return nil, nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+		assert.ElementsMatch(t, []string{"A", "B"}, rec.destClosedDBs)
+	})
+	t.Run("bidirectional mode should copy the missing keys in both directions", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "A-val-s-0",
+				"A-key-1": "dest",
+				"A-key-5": "A-val-d-5",
+
+				"B-key-1": "B-val-s-1",
+				"B-key-6": "B-val-d-6",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		srcPutOps := make(map[string]string)
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Bidirectional = true
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			assert.NotEqual(t, SourceRole, role)
+			if role == WritableSourceRole {
+				wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+					for i := range keys {
+						srcPutOps[string(keys[i])] = string(values[i])
+					}
+
+					return nil
+				}
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.Equal(t, 3, result.numInserts)
+		assert.Equal(t, 1, result.numReverseInserts)
+		assert.Equal(t, 1, result.numConflicts)
+		assert.Equal(t, 0, result.numOverwrites)
+		assert.Equal(t, len("A-key-5A-val-d-5"), result.numReverseBytes)
+
+		err = handler.Process()
+		assert.Nil(t, err)
+
+		expectedPutOperations := map[string]string{
+			"A-key-2": "A-val-s-2",
+			"A-key-3": "A-val-s-3",
+			"A-key-4": "A-val-s-4",
+			"B-key-0": "B-val-s-0",
+			"B-key-2": "B-val-s-2",
+			"B-key-3": "B-val-s-3",
+			"B-key-4": "B-val-s-4",
+		}
+		expectedSrcPutOperations := map[string]string{
+			"A-key-5": "A-val-d-5",
+			"B-key-6": "B-val-d-6",
+		}
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+		assert.Equal(t, expectedSrcPutOperations, srcPutOps)
+	})
+	t.Run("bidirectional mode in dry run should not write anything", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-5": "A-val-d-5",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Bidirectional = true
+		args.DryRun = true
+		args.DBWrapperFactory = wrapFactory(createDryRunFactoryForProcess(t, test, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+				assert.Fail(t, "should have not called WriteBatch in dry run mode")
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.Equal(t, 5, result.numInserts)
+		assert.Equal(t, 1, result.numReverseInserts)
+	})
+	t.Run("mirror mode should remove the destination keys missing from the source", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-5": "A-val-d-5",
+				"A-key-6": "A-val-d-6",
+
+				"B-key-1": "B-val-s-1",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		removedKeys := make([]string, 0)
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		args.MaxDeletions = 2
+		args.MaxDeletionsPercent = 100
+		args.SyncWrites = true
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role != DestinationRole {
+				return
+			}
+
+			wrapper.RemoveCalled = func(key []byte) error {
+				assert.Fail(t, "should have removed the keys in the write batch")
+				return nil
+			}
+			writeBatch := wrapper.WriteBatchCalled
+			wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, batchRemovedKeys [][]byte, sync bool) error {
+				assert.True(t, sync)
+				for _, key := range batchRemovedKeys {
+					removedKeys = append(removedKeys, string(key))
+				}
+
+				return writeBatch(keys, values, batchRemovedKeys, sync)
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.Equal(t, 4, result.numInserts)
+		assert.Equal(t, 1, result.numOverwrites)
+		assert.Equal(t, 2, result.numRemovals)
+		assert.Equal(t, []string{"A-key-5", "A-key-6"}, removedKeys)
+
+		results, err = handler.processDB("B", paths{src: "B", dest: "B"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result = results[0]
+		assert.Equal(t, 0, result.numRemovals)
+		assert.Equal(t, []string{"A-key-5", "A-key-6"}, removedKeys)
+	})
+	t.Run("mirror mode should not remove anything if the maximum deletions is exceeded", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-5": "A-val-d-5",
+				"A-key-6": "A-val-d-6",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		args.MaxDeletions = 1
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.RemoveCalled = func(key []byte) error {
+				assert.Fail(t, "should have not called Remove")
+				return nil
+			}
+			wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+				assert.Fail(t, "should have not called WriteBatch")
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		_, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.ErrorIs(t, err, errDeletionsCapExceeded)
+		assert.Contains(t, err.Error(), "2 keys to remove, maximum 1")
+	})
+	t.Run("mirror mode should not remove anything if the maximum deletions percent is exceeded", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "A-val-s-0",
+				"A-key-1": "A-val-s-1",
+				"A-key-2": "A-val-s-2",
+				"A-key-5": "A-val-d-5",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		args.MaxDeletionsPercent = 20
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.RemoveCalled = func(key []byte) error {
+				assert.Fail(t, "should have not called Remove")
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		_, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.ErrorIs(t, err, errDeletionsCapExceeded)
+		assert.Contains(t, err.Error(), "1 keys to remove out of 4 (25.00%), maximum 20.00%")
+	})
+	t.Run("mirror mode in dry run should only count the keys to remove", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-5": "A-val-d-5",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		args.DryRun = true
+		args.DBWrapperFactory = wrapFactory(createDryRunFactoryForProcess(t, test, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.RemoveCalled = func(key []byte) error {
+				assert.Fail(t, "should have not called Remove in dry run mode")
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.Equal(t, 1, result.numRemovals)
+	})
+	t.Run("mirror mode with write batch error should error and not record the failed batch progress", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-5": "A-val-d-5",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Mirror = true
+		args.MirrorConfirmed = true
+		savedKeys := make([]string, 0)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			SaveProgressCalled: func(name string, lastKey []byte) error {
+				savedKeys = append(savedKeys, string(lastKey))
+				return nil
+			},
+		}
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+				if len(removedKeys) > 0 {
+					return expectedErr
+				}
+
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		_, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Contains(t, err.Error(), "while writing a batch of")
+		assert.Equal(t, []string{"A-key-1", "A-key-3"}, savedKeys)
+	})
+	t.Run("merge-join strategy should resume after the last committed key", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"B-key-0": "dest",
+				"B-key-2": "dest",
+				"B-key-3": "B-val-s-3",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			IsCompletedCalled: func(name string) bool {
+				return name == "A"
+			},
+			LastKeyCalled: func(name string) []byte {
+				return []byte("B-key-1")
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedPutOperations := map[string]string{
+			"B-key-2": "B-val-s-2",
+			"B-key-4": "B-val-s-4",
+		}
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+	})
+	t.Run("destination lookup error should error", func(t *testing.T) {
+		test := &testHandler{}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, test, rec)
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == DestinationRole {
+				wrapper.GetCalled = func(key []byte) ([]byte, error) {
+					return nil, expectedErr
+				}
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		_, err := handler.processDB("A", paths{src: "A", dest: "dest/A"})
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Contains(t, err.Error(), "dest path dest/A")
+		assert.Empty(t, rec.putOps)
+		assert.Equal(t, []string{"dest/A"}, rec.destClosedDBs)
+	})
+	t.Run("merge-join strategy with destination iteration error should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == DestinationRole {
+				wrapper.NewIteratorCalled = func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+					return nil, expectedErr
+				}
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, rec.destOpenedDBs, rec.destClosedDBs)
+	})
+	t.Run("overwrite with source policy should replace the conflicting values", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-1": "A-val-s-1",
+				"B-key-1": "B-val-s-1",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedPutOperations := map[string]string{
+			"A-key-0": "A-val-s-0",
+			"A-key-2": "A-val-s-2",
+			"A-key-3": "A-val-s-3",
+			"A-key-4": "A-val-s-4",
+			"B-key-0": "B-val-s-0",
+			"B-key-2": "B-val-s-2",
+			"B-key-3": "B-val-s-3",
+			"B-key-4": "B-val-s-4",
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+	})
+	t.Run("fail policy should error and close the DBs", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-2": "dest",
+				"B-key-2": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.ConflictResolver, _ = NewConflictResolver(FailPolicy)
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.ErrorIs(t, err, errConflictingValuesFound)
+
+		assert.Equal(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+		assert.Equal(t, rec.destOpenedDBs, rec.destClosedDBs)
+		assert.Equal(t, 1, len(rec.srcOpenedDBs))
+		assert.Equal(t, 2, len(rec.putOps))
+	})
+	t.Run("should record the source snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		test := &testHandler{
+			getOps: make(map[string]string),
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		snapshot := &common.SnapshotInfo{
+			Sequence: 37,
+			Time:     time.Now(),
+		}
+		args := setupForProcess(t, test, rec)
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == SourceRole {
+				wrapper.SnapshotInfoCalled = func() *common.SnapshotInfo {
+					return snapshot
+				}
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.Equal(t, 5, result.numInserts)
+		assert.True(t, result.snapshot == snapshot)
+	})
+	t.Run("dry run should not write anything", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-1": "A-val-s-1",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.DryRun = true
+		args.DBWrapperFactory = wrapFactory(createDryRunFactoryForProcess(t, test, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+				assert.Fail(t, "should have not called WriteBatch in dry run mode")
+				return nil
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+
+		expectedResult := dbResult{
+			dest:          "A",
+			numInserts:    3,
+			numConflicts:  1,
+			numOverwrites: 1,
+			numBytes:      4 * len("A-key-0A-val-s-0"),
+			sampleKeys: []string{
+				hex.EncodeToString([]byte("A-key-0")),
+				hex.EncodeToString([]byte("A-key-2")),
+				hex.EncodeToString([]byte("A-key-3")),
+				hex.EncodeToString([]byte("A-key-4")),
+			},
+		}
+		assert.Equal(t, expectedResult, result)
+		assert.Equal(t, []string{"A"}, rec.srcClosedDBs)
+		assert.Equal(t, []string{"A"}, rec.destClosedDBs)
+
+		err = handler.Process()
+		assert.Nil(t, err)
+		assert.Empty(t, rec.putOps)
+	})
+	t.Run("should skip completed DBs and resume after the last committed key", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		completed := make([]string, 0)
+		cleared := false
+		args := setupForProcess(t, test, rec)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			IsCompletedCalled: func(name string) bool {
+				return name == "A"
+			},
+			LastKeyCalled: func(name string) []byte {
+				assert.Equal(t, "B", name)
+				return []byte("B-key-2")
+			},
+			MarkCompletedCalled: func(name string) error {
+				completed = append(completed, name)
+				return nil
+			},
+			ClearCalled: func() error {
+				assert.Equal(t, []string{"B"}, completed)
+				cleared = true
+				return nil
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+		assert.True(t, cleared)
+
+		expectedPutOperations := map[string]string{
+			"B-key-3": "B-val-s-3",
+			"B-key-4": "B-val-s-4",
+		}
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+		assert.Equal(t, []string{"B"}, rec.srcOpenedDBs)
+		assert.Equal(t, []string{"B"}, completed)
+	})
+	t.Run("checkpoint clear error should error", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, test, rec)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			ClearCalled: func() error {
+				return expectedErr
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Equal(t, expectedErr, err)
+	})
+	t.Run("dry run should use an empty DB for the destinations that are not level DBs", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		test := &testHandler{}
+		args := setupForProcess(t, test, rec)
+		args.DryRun = true
+		args.DBWrapperFactory = wrapFactory(createDryRunFactoryForProcess(t, test, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			openCalled := wrapper.OpenCalled
+			wrapper.OpenCalled = func(path string) error {
+				if path == "dest/A" {
+					return fmt.Errorf("%w, path %s", errNotALevelDB, path)
+				}
+				return openCalled(path)
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("A", paths{src: "A", dest: "dest/A"})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, 5, results[0].numInserts)
+		assert.Empty(t, rec.putOps)
+	})
+	t.Run("dry run should not record the progress", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.DryRun = true
+		args.DBWrapperFactory = createDryRunFactoryForProcess(t, test, rec)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			SaveProgressCalled: func(name string, lastKey []byte) error {
+				assert.Fail(t, "should have not called SaveProgress in dry run mode")
+				return nil
+			},
+			MarkCompletedCalled: func(name string) error {
+				assert.Fail(t, "should have not called MarkCompleted in dry run mode")
+				return nil
+			},
+			ClearCalled: func() error {
+				assert.Fail(t, "should have not called Clear in dry run mode")
+				return nil
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+	})
+	t.Run("should work with multiple workers", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"B-key-1": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B", "C", "D", "E"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B", "C", "D", "F"}
+			},
+		}
+		args.NumWorkers = 3
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedDBs := []string{"A", "B", "C", "D"}
+		assert.ElementsMatch(t, expectedDBs, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, expectedDBs, rec.srcClosedDBs)
+		assert.ElementsMatch(t, expectedDBs, rec.destOpenedDBs)
+		assert.ElementsMatch(t, expectedDBs, rec.destClosedDBs)
+		assert.Equal(t, 4*5-2, len(rec.putOps))
+		assert.Equal(t, "D-val-s-3", rec.putOps["D-key-3"])
+	})
+	t.Run("error in one worker should stop the dispatch", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, test, rec)
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == SourceRole {
+				wrapper.OpenCalled = func(path string) error {
+					return expectedErr
+				}
+			}
+		})
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			ClearCalled: func() error {
+				assert.Fail(t, "should have not called Clear after an error")
+				return nil
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Equal(t, expectedErr, err)
+		assert.Empty(t, rec.destOpenedDBs)
+	})
+	t.Run("DB wrapper factory error should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DBWrapperFactory = &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				return nil, expectedErr
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Equal(t, expectedErr, err)
+	})
+	t.Run("should write in batches and save the progress after each batch", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		batches := make([][]string, 0)
+		savedKeys := make([]string, 0)
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A"}
+			},
+		}
+		args.SyncWrites = true
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			SaveProgressCalled: func(name string, lastKey []byte) error {
+				savedKeys = append(savedKeys, string(lastKey))
+				return nil
+			},
+		}
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == DestinationRole {
+				wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+					assert.True(t, sync)
+					batch := make([]string, 0, len(keys))
+					for _, key := range keys {
+						batch = append(batch, string(key))
+					}
+					batches = append(batches, batch)
+
+					return nil
+				}
+			}
+		})
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedBatches := [][]string{
+			{"A-key-0", "A-key-1"},
+			{"A-key-2", "A-key-3"},
+			{"A-key-4"},
+		}
+		assert.Equal(t, expectedBatches, batches)
+		assert.Equal(t, []string{"A-key-1", "A-key-3"}, savedKeys)
+	})
+	t.Run("batch write error should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			SaveProgressCalled: func(name string, lastKey []byte) error {
+				assert.Fail(t, "should have not saved the progress")
+				return nil
+			},
+		}
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == DestinationRole {
+				wrapper.WriteBatchCalled = func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+					return expectedErr
+				}
+			}
+		})
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Contains(t, err.Error(), "while writing a batch of 2 entries")
+		assert.Equal(t, rec.destOpenedDBs, rec.destClosedDBs)
+	})
+	t.Run("locked DBs should error before opening any DB", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.LockChecker = &testcommon.LockCheckerStub{
+			IsLockedCalled: func(path string) (bool, error) {
+				return path == "B", nil
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.ErrorIs(t, err, errLockedDBs)
+		assert.Contains(t, err.Error(), ": B, B")
+		assert.Empty(t, rec.srcOpenedDBs)
+		assert.Empty(t, rec.destOpenedDBs)
+	})
+	t.Run("lock checker error should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		expectedErr := fmt.Errorf("expected error")
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.LockChecker = &testcommon.LockCheckerStub{
+			IsLockedCalled: func(path string) (bool, error) {
+				return false, expectedErr
+			},
+		}
 
-		assert.Nil(t, handler)
-		assert.Equal(t, errNilDirectoriesHandler, err)
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Empty(t, rec.srcOpenedDBs)
 	})
-	t.Run("nil source DB wrapper should error", func(t *testing.T) {
-		t.Parallel()
+	t.Run("should wait for the locked DBs to be released", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
 
-		handler, err := NewDataCopyHandler(
-			&testcommon.DirectoriesHandlerStub{},
-			nil,
-			&testcommon.DBWrapperStub{},
-		)
+		numChecks := 0
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.WaitForLock = time.Minute
+		args.LockChecker = &testcommon.LockCheckerStub{
+			IsLockedCalled: func(path string) (bool, error) {
+				numChecks++
+				return numChecks <= 8, nil
+			},
+		}
 
-		assert.Nil(t, handler)
-		assert.ErrorIs(t, err, errNilDBWrapper)
-		assert.Contains(t, err.Error(), "for the source DB wrapper")
+		handler, _ := NewDataCopyHandler(args)
+		handler.lockRetryDelay = time.Millisecond
+		err := handler.Process()
+		assert.Nil(t, err)
+		assert.Equal(t, 12, numChecks)
+		assert.Equal(t, 10, len(rec.putOps))
 	})
-	t.Run("nil destination DB wrapper should error", func(t *testing.T) {
-		t.Parallel()
+	t.Run("should error if the DBs are not released in time", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
 
-		handler, err := NewDataCopyHandler(
-			&testcommon.DirectoriesHandlerStub{},
-			&testcommon.DBWrapperStub{},
-			nil,
-		)
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.WaitForLock = time.Millisecond * 50
+		args.LockChecker = &testcommon.LockCheckerStub{
+			IsLockedCalled: func(path string) (bool, error) {
+				return path == "A", nil
+			},
+		}
 
-		assert.Nil(t, handler)
-		assert.ErrorIs(t, err, errNilDBWrapper)
-		assert.Contains(t, err.Error(), "for the destination DB wrapper")
+		handler, _ := NewDataCopyHandler(args)
+		handler.lockRetryDelay = time.Millisecond
+		err := handler.Process()
+		assert.ErrorIs(t, err, errLockedDBs)
+		assert.Contains(t, err.Error(), ": A, A")
+		assert.Empty(t, rec.srcOpenedDBs)
 	})
-	t.Run("should work", func(t *testing.T) {
-		t.Parallel()
+	t.Run("should pair the directories by their relative path", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceParentDirectoriesCalled: func() []string {
+				return []string{"/src"}
+			},
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"/dest"}
+			},
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"/src/Epoch_1/Shard_0/A", "/src/Epoch_2/Shard_0/A", "/src/Static/Shard_0/B"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"/dest/Epoch_1/Shard_0/A", "/dest/Epoch_3/Shard_0/A", "/dest/Static/Shard_0/B"}
+			},
+		}
 
-		handler, err := NewDataCopyHandler(
-			&testcommon.DirectoriesHandlerStub{},
-			&testcommon.DBWrapperStub{},
-			&testcommon.DBWrapperStub{},
-		)
+		handler, _ := NewDataCopyHandler(args)
+		selection, err := handler.computeCommonDirs()
+		assert.Nil(t, err)
 
-		assert.NotNil(t, handler)
+		expectedCommonDirs := map[string]paths{
+			"Epoch_1/Shard_0/A": {src: "/src/Epoch_1/Shard_0/A", dest: "/dest/Epoch_1/Shard_0/A", destParentDir: "/dest"},
+			"Static/Shard_0/B":  {src: "/src/Static/Shard_0/B", dest: "/dest/Static/Shard_0/B", destParentDir: "/dest"},
+		}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"Epoch_1/Shard_0/A", "Static/Shard_0/B"}, selection.names)
+		assert.Equal(t, []string{"Epoch_1/Shard_0/A", "Static/Shard_0/B"}, selection.common)
+		assert.Equal(t, []string{"Epoch_2/Shard_0/A"}, selection.srcOnly)
+		assert.Equal(t, []string{"Epoch_3/Shard_0/A"}, selection.destOnly)
+
+		handler.createMissing = true
+		selection, err = handler.computeCommonDirs()
 		assert.Nil(t, err)
+		expectedCommonDirs["Epoch_2/Shard_0/A"] = paths{src: "/src/Epoch_2/Shard_0/A", dest: "/dest/Epoch_2/Shard_0/A", destParentDir: "/dest", create: true}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"Epoch_1/Shard_0/A", "Epoch_2/Shard_0/A", "Static/Shard_0/B"}, selection.names)
+		assert.Equal(t, []string{"Epoch_1/Shard_0/A", "Static/Shard_0/B"}, selection.common)
 	})
-}
+	t.Run("should merge the directories from all the source parent directories", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
 
-func TestDataCopyHandler_Process(t *testing.T) {
-	t.Parallel()
+		sourceDirs := map[string][]string{
+			"/src1": {"/src1/A", "/src1/B", "/src1/E"},
+			"/src2": {"/src2/A", "/src2/C", "/src2/E"},
+			"/src3": {"/src3/A", "/src3/B", "/src3/D"},
+		}
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceParentDirectoriesCalled: func() []string {
+				return []string{"/src1", "/src2", "/src3"}
+			},
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"/dest"}
+			},
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return sourceDirs[parentDir]
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"/dest/A", "/dest/B", "/dest/C", "/dest/E"}
+			},
+			IsSelectedCalled: func(name string) bool {
+				return name != "E"
+			},
+		}
 
-	t.Run("should work", func(t *testing.T) {
+		handler, _ := NewDataCopyHandler(args)
+		selection, err := handler.computeCommonDirs()
+		assert.Nil(t, err)
+
+		expectedCommonDirs := map[string]paths{
+			"A": {src: "/src1/A", extraSrcs: []string{"/src2/A", "/src3/A"}, dest: "/dest/A", destParentDir: "/dest"},
+			"B": {src: "/src1/B", extraSrcs: []string{"/src3/B"}, dest: "/dest/B", destParentDir: "/dest"},
+			"C": {src: "/src2/C", dest: "/dest/C", destParentDir: "/dest"},
+		}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"A", "B", "C"}, selection.names)
+		assert.Equal(t, []string{"D"}, selection.srcOnly)
+		assert.Equal(t, []string{"E"}, selection.excluded)
+		assert.Empty(t, selection.destOnly)
+	})
+	t.Run("should merge the keys from all the source DBs, the first source winning", func(t *testing.T) {
 		test := &testHandler{
 			getOps: map[string]string{
-				"A-key-0": "dest",
-				"A-key-1": "dest",
-				"A-key-4": "dest",
+				"A-key-1": "A-val-s-1",
+			},
+		}
 
-				"B-key-1": "dest",
-				"B-key-2": "dest",
-				"B-key-3": "dest",
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		lockedPaths := make([]string, 0)
+		args := setupForProcess(t, test, rec)
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.LockChecker = &testcommon.LockCheckerStub{
+			IsLockedCalled: func(path string) (bool, error) {
+				lockedPaths = append(lockedPaths, path)
+				return false, nil
+			},
+		}
+		keysAndValues := map[string][]string{
+			"src1/A": {"A-key-0", "first", "A-key-1", "A-val-s-1"},
+			"src2/A": {"A-key-0", "second", "A-key-2", "second"},
+			"dest/A": {"A-key-1", "A-val-s-1"},
+		}
+		factory := args.DBWrapperFactory
+		args.DBWrapperFactory = &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				wrapper, err := factory.Create(path, role)
+				wrapper.(*testcommon.DBWrapperStub).NewIteratorCalled = func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+					return createIterator(keysAndValues[path]...), nil
+				}
+
+				return wrapper, err
+			},
+		}
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceParentDirectoriesCalled: func() []string {
+				return []string{"src1", "src2"}
+			},
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{parentDir + "/A"}
+			},
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"dest"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"dest/A"}
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedPutOperations := map[string]string{
+			"A-key-0": "first",
+			"A-key-2": "second",
+		}
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+		assert.Equal(t, []string{"src1/A", "src2/A", "dest/A"}, lockedPaths)
+		assert.Equal(t, []string{"src1/A", "src2/A"}, rec.srcOpenedDBs)
+		assert.Equal(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+	})
+	t.Run("bidirectional mode with multiple source parent directories should error", func(t *testing.T) {
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Bidirectional = true
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceParentDirectoriesCalled: func() []string {
+				return []string{"src1", "src2"}
+			},
+		}
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("bidirectional mode with multiple destination parent directories should error", func(t *testing.T) {
+		args := createMockArgsDataCopyHandler()
+		args.CopyStrategy = MergeJoinCopyStrategy
+		args.Bidirectional = true
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"dest1", "dest2"}
+			},
+		}
+		handler, err := NewDataCopyHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("should select the directories from all the destination parent directories", func(t *testing.T) {
+		args := setupForProcess(t, &testHandler{}, &recorder{})
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B", "C"}
+			},
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"dest1", "dest2"}
 			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				if parentDir == "dest1" {
+					return []string{"dest1/A", "dest1/B", "dest1/D"}
+				}
+				return []string{"dest2/A", "dest2/D"}
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+
+		selection, err := handler.computeCommonDirs()
+		assert.Nil(t, err)
+
+		expectedCommonDirs := map[string]paths{
+			"A": {src: "A", dest: "dest1/A", destParentDir: "dest1", extraDests: []destPath{{path: "dest2/A", parentDir: "dest2"}}},
+			"B": {src: "B", dest: "dest1/B", destParentDir: "dest1"},
 		}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"A", "B"}, selection.common)
+		assert.Equal(t, []string{"B (dest2)", "C (dest1, dest2)"}, selection.srcOnly)
+		assert.Equal(t, []string{"D (dest1)", "D (dest2)"}, selection.destOnly)
+
+		handler.createMissing = true
+		selection, err = handler.computeCommonDirs()
+		assert.Nil(t, err)
 
+		expectedCommonDirs["B"] = paths{src: "B", dest: "dest1/B", destParentDir: "dest1",
+			extraDests: []destPath{{path: "dest2/B", parentDir: "dest2", create: true}}}
+		expectedCommonDirs["C"] = paths{src: "C", dest: "dest1/C", destParentDir: "dest1", create: true,
+			extraDests: []destPath{{path: "dest2/C", parentDir: "dest2", create: true}}}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"A", "B", "C"}, selection.names)
+	})
+	for _, copyStrategy := range []string{GetCopyStrategy, MergeJoinCopyStrategy} {
+		copyStrategy := copyStrategy
+		t.Run("should fill all the destination DBs reading the source DB once with the "+copyStrategy+" strategy", func(t *testing.T) {
+			testFanOutCopy(t, copyStrategy)
+		})
+	}
+	t.Run("should pair the directories as mapped by the directories handler", func(t *testing.T) {
 		rec := &recorder{
 			putOps: make(map[string]string),
 		}
 
-		expectedDBOperationOrder := []string{"A", "B"}
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"Epoch_1200", "Static", "OldUnit"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"Epoch_1201", "Static", "Epoch_1200"}
+			},
+			DestinationNameCalled: func(sourceName string) string {
+				if sourceName == "Epoch_1200" {
+					return "Epoch_1201"
+				}
+				if sourceName == "OldUnit" {
+					return "NewUnit"
+				}
+
+				return sourceName
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		selection, err := handler.computeCommonDirs()
+		assert.Nil(t, err)
+
+		expectedCommonDirs := map[string]paths{
+			"Epoch_1200": {src: "Epoch_1200", dest: "Epoch_1201"},
+			"Static":     {src: "Static", dest: "Static"},
+		}
+		assert.Equal(t, expectedCommonDirs, selection.dirs)
+		assert.Equal(t, []string{"Epoch_1200", "Static"}, selection.names)
+		assert.Equal(t, []string{"Epoch_1200 -> Epoch_1201", "Static"}, selection.common)
+		assert.Equal(t, []string{"OldUnit -> NewUnit"}, selection.srcOnly)
+		assert.Equal(t, []string{"Epoch_1200"}, selection.destOnly)
+
+		handler.createMissing = true
+		selection, err = handler.computeCommonDirs()
+		assert.Nil(t, err)
+		assert.Equal(t, paths{src: "OldUnit", dest: "NewUnit", create: true}, selection.dirs["OldUnit"])
+	})
+	t.Run("should process only the selected directories", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B", "C", "E"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B", "C", "F"}
+			},
+			IsSelectedCalled: func(name string) bool {
+				return name != "B" && name != "E" && name != "F"
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		selection, err := handler.computeCommonDirs()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"A", "C"}, selection.names)
+		assert.Equal(t, []string{"A", "C"}, selection.common)
+		assert.Equal(t, []string{"B", "E"}, selection.excluded)
+		assert.Empty(t, selection.srcOnly)
+		assert.Empty(t, selection.destOnly)
+
+		err = handler.Process()
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"A", "C"}, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, []string{"A", "C"}, rec.destOpenedDBs)
+	})
+	t.Run("should process only the keys from the key range", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{getOps: map[string]string{}}, rec)
+		args.KeyRange = KeyRange{Start: []byte("A-key-1"), End: []byte("A-key-3")}
+		args.CheckpointHandler = &testcommon.CheckpointHandlerStub{
+			LastKeyCalled: func(name string) []byte {
+				if name == "A" {
+					return []byte("A-key-0")
+				}
+
+				return nil
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
 		expectedPutOperations := map[string]string{
+			"A-key-1": "A-val-s-1",
 			"A-key-2": "A-val-s-2",
-			"A-key-3": "A-val-s-3",
-			"B-key-0": "B-val-s-0",
-			"B-key-4": "B-val-s-4",
+		}
+		assert.Equal(t, expectedPutOperations, rec.putOps)
+	})
+	t.Run("iteration error should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
 		}
 
-		handler, _ := NewDataCopyHandler(setupForProcess(t, test, rec))
+		expectedErr := fmt.Errorf("expected error")
+		iterator := testcommon.NewInMemoryIteratorWithError(expectedErr)
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == SourceRole {
+				wrapper.NewIteratorCalled = func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+					return iterator, nil
+				}
+			}
+		})
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Equal(t, expectedErr, err)
+		assert.True(t, iterator.IsReleased())
+		assert.Equal(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+	})
+	t.Run("multiple source DBs mapped to the same destination DB should error", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A", "B"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{"A"}
+			},
+			DestinationNameCalled: func(sourceName string) string {
+				return "A"
+			},
+		}
+
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.ErrorIs(t, err, errDuplicatedDestination)
+		assert.Contains(t, err.Error(), "are mapped to A")
+		assert.Empty(t, rec.srcOpenedDBs)
+	})
+	t.Run("create missing should fully copy the source-only DBs", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"C-key-0": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, test, rec)
+		args.CreateMissing = true
+		args.DBWrapperFactory = wrapFactory(args.DBWrapperFactory, func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			if role == DestinationRole {
+				getHandler := wrapper.GetCalled
+				wrapper.GetCalled = func(key []byte) ([]byte, error) {
+					assert.NotEqual(t, "C", string(bytes.Split(key, []byte("-"))[0]), "should have not called Get on a created DB")
+					return getHandler(key)
+				}
+			}
+		})
+		handler, _ := NewDataCopyHandler(args)
 		err := handler.Process()
 		assert.Nil(t, err)
 
-		assert.ElementsMatch(t, expectedDBOperationOrder, rec.srcOpenedDBs)
-		assert.ElementsMatch(t, expectedDBOperationOrder, rec.srcClosedDBs)
-		assert.ElementsMatch(t, expectedDBOperationOrder, rec.destOpenedDBs)
-		assert.ElementsMatch(t, expectedDBOperationOrder, rec.destClosedDBs)
-		assert.Equal(t, expectedPutOperations, rec.putOps)
+		expectedDBs := []string{"A", "B", "C"}
+		assert.ElementsMatch(t, expectedDBs, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, expectedDBs, rec.destOpenedDBs)
+		assert.ElementsMatch(t, expectedDBs, rec.destClosedDBs)
+		assert.Equal(t, 3*5-1, len(rec.putOps))
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, fmt.Sprintf("C-val-s-%d", i), rec.putOps[fmt.Sprintf("C-key-%d", i)])
+		}
+	})
+	t.Run("create missing in dry run should not touch the missing destination DBs", func(t *testing.T) {
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		args := setupForProcess(t, &testHandler{}, rec)
+		args.CreateMissing = true
+		args.DryRun = true
+		handler, _ := NewDataCopyHandler(args)
+
+		results, err := handler.processDB("C", paths{src: "C", dest: "C", create: true})
+		assert.Nil(t, err)
+		require.Len(t, results, 1)
+		result := results[0]
+		assert.True(t, result.created)
+		assert.Equal(t, 5, result.numInserts)
+		assert.Equal(t, []string{"C"}, rec.srcClosedDBs)
+		assert.Empty(t, rec.destOpenedDBs)
+		assert.Empty(t, rec.putOps)
+	})
+	t.Run("custom conflict resolver should be called only for different values", func(t *testing.T) {
+		test := &testHandler{
+			getOps: map[string]string{
+				"A-key-0": "dest",
+				"A-key-1": "A-val-s-1",
+				"A-key-2": "A-val-s-2",
+				"A-key-3": "A-val-s-3",
+				"A-key-4": "A-val-s-4",
+				"B-key-0": "B-val-s-0",
+				"B-key-1": "B-val-s-1",
+				"B-key-2": "B-val-s-2",
+				"B-key-3": "B-val-s-3",
+				"B-key-4": "dest",
+			},
+		}
+
+		rec := &recorder{
+			putOps: make(map[string]string),
+		}
+
+		conflicts := make(map[string]string)
+		args := setupForProcess(t, test, rec)
+		args.ConflictResolver = &testcommon.ConflictResolverStub{
+			ShouldOverwriteCalled: func(key []byte, srcVal []byte, destVal []byte) (bool, error) {
+				conflicts[string(key)] = string(srcVal) + "/" + string(destVal)
+				return string(key) == "B-key-4", nil
+			},
+		}
+		handler, _ := NewDataCopyHandler(args)
+		err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedConflicts := map[string]string{
+			"A-key-0": "A-val-s-0/dest",
+			"B-key-4": "B-val-s-4/dest",
+		}
+		assert.Equal(t, expectedConflicts, conflicts)
+		assert.Equal(t, map[string]string{"B-key-4": "B-val-s-4"}, rec.putOps)
 	})
 }
 
-func setupForProcess(t *testing.T, test *testHandler, recorder *recorder) (DirectoriesHandler, DBWrapper, DBWrapper) {
+func testFanOutCopy(t *testing.T, copyStrategy string) {
+	keysAndValues := map[string][]string{
+		"src/A":   {"a", "1", "b", "2", "c", "3"},
+		"dest1/A": {"b", "2", "c", "x"},
+	}
+	putOps := make(map[string]map[string]string)
+	numSrcIterators := 0
+	args := setupForProcess(t, &testHandler{}, &recorder{})
+	args.CopyStrategy = copyStrategy
+	args.CreateMissing = true
+	args.DBWrapperFactory = &dbWrapperFactoryStub{
+		createCalled: func(path string, role DBRole) (DBWrapper, error) {
+			putOps[path] = make(map[string]string)
+			return &testcommon.DBWrapperStub{
+				NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+					if role == SourceRole {
+						numSrcIterators++
+					}
+					return createIterator(keysAndValues[path]...), nil
+				},
+				GetCalled: func(key []byte) ([]byte, error) {
+					values := keysAndValues[path]
+					for i := 0; i < len(values); i += 2 {
+						if values[i] == string(key) {
+							return []byte(values[i+1]), nil
+						}
+					}
+					return nil, leveldb.ErrNotFound
+				},
+				WriteBatchCalled: func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+					for i := range keys {
+						putOps[path][string(keys[i])] = string(values[i])
+					}
+					return nil
+				},
+			}, nil
+		},
+	}
+	args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+		SourceParentDirectoriesCalled: func() []string {
+			return []string{"src"}
+		},
+		SourceDirectoriesCalled: func(parentDir string) []string {
+			return []string{"src/A"}
+		},
+		DestinationParentDirectoriesCalled: func() []string {
+			return []string{"dest1", "dest2"}
+		},
+		DestinationDirectoriesCalled: func(parentDir string) []string {
+			if parentDir == "dest1" {
+				return []string{"dest1/A"}
+			}
+			return make([]string, 0)
+		},
+	}
+	handler, _ := NewDataCopyHandler(args)
+
+	selection, err := handler.computeCommonDirs()
+	require.Nil(t, err)
+	results, err := handler.processDB("A", selection.dirs["A"])
+	assert.Nil(t, err)
+	assert.Equal(t, 1, numSrcIterators)
+	assert.Equal(t, map[string]string{"a": "1"}, putOps["dest1/A"])
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, putOps["dest2/A"])
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "dest1/A", results[0].dest)
+	assert.Equal(t, "dest1", results[0].destParentDir)
+	assert.False(t, results[0].created)
+	assert.Equal(t, 1, results[0].numInserts)
+	assert.Equal(t, 1, results[0].numConflicts)
+	assert.Equal(t, "dest2/A", results[1].dest)
+	assert.Equal(t, "dest2", results[1].destParentDir)
+	assert.True(t, results[1].created)
+	assert.Equal(t, 3, results[1].numInserts)
+	assert.Equal(t, 0, results[1].numConflicts)
+}
+
+func setupForProcess(t *testing.T, test *testHandler, recorder *recorder) ArgsDataCopyHandler {
 	directoriesHandlerInstance := &testcommon.DirectoriesHandlerStub{
-		SourceDirectoriesCalled: func() []string {
+		SourceDirectoriesCalled: func(parentDir string) []string {
 			return []string{"A", "B", "C"}
 		},
-		DestinationDirectoriesCalled: func() []string {
+		DestinationDirectoriesCalled: func(parentDir string) []string {
 			return []string{"A", "B", "D"}
 		},
 	}
 
+	conflictResolver, _ := NewConflictResolver(KeepDestinationPolicy)
+
+	return ArgsDataCopyHandler{
+		DirectoriesHandler: directoriesHandlerInstance,
+		DBWrapperFactory: &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				if role == SourceRole || role == WritableSourceRole {
+					return createSrcDBWrapperForProcess(t, recorder), nil
+				}
+
+				return createDestDBWrapperForProcess(t, test, recorder), nil
+			},
+		},
+		NumWorkers:        1,
+		ConflictResolver:  conflictResolver,
+		CheckpointHandler: NewDisabledCheckpointHandler(),
+		LockChecker:       &testcommon.LockCheckerStub{},
+		BatchSize:         2,
+		CopyStrategy:      GetCopyStrategy,
+	}
+}
+
+// createDryRunFactoryForProcess returns the process factory for the dry-run mode, where the destination DBs are also
+// opened with the source role. Each DB being processed creates its source wrapper first, then its single destination
+func createDryRunFactoryForProcess(t *testing.T, test *testHandler, recorder *recorder) DBWrapperFactory {
+	isSrcCreated := false
+	return &dbWrapperFactoryStub{
+		createCalled: func(path string, role DBRole) (DBWrapper, error) {
+			assert.Equal(t, SourceRole, role, "all the DBs should be opened in read-only mode in dry run mode")
+
+			isSrcCreated = !isSrcCreated
+			if isSrcCreated {
+				return createSrcDBWrapperForProcess(t, recorder), nil
+			}
+
+			return createDestDBWrapperForProcess(t, test, recorder), nil
+		},
+	}
+}
+
+func wrapFactory(factory DBWrapperFactory, changeWrapper func(wrapper *testcommon.DBWrapperStub, role DBRole)) DBWrapperFactory {
+	return &dbWrapperFactoryStub{
+		createCalled: func(path string, role DBRole) (DBWrapper, error) {
+			wrapper, err := factory.Create(path, role)
+			changeWrapper(wrapper.(*testcommon.DBWrapperStub), role)
+
+			return wrapper, err
+		},
+	}
+}
+
+func createSrcDBWrapperForProcess(t *testing.T, recorder *recorder) DBWrapper {
 	var currentSrcDB []byte
 	srcDbWrapper := &testcommon.DBWrapperStub{
 		OpenCalled: func(path string) error {
 			currentSrcDB = []byte(path)
+			recorder.mut.Lock()
 			recorder.srcOpenedDBs = append(recorder.srcOpenedDBs, path)
+			recorder.mut.Unlock()
 
 			return nil
 		},
-		RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+		NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+			keys := make([][]byte, 0, 5)
+			values := make([][]byte, 0, 5)
 			for i := 0; i < 5; i++ {
-				handler(
-					[]byte(fmt.Sprintf("%s-key-%d", string(currentSrcDB), i)),
-					[]byte(fmt.Sprintf("%s-val-s-%d", string(currentSrcDB), i)),
-				)
+				key := []byte(fmt.Sprintf("%s-key-%d", string(currentSrcDB), i))
+				if bytes.Compare(key, startKey) < 0 {
+					continue
+				}
+				if endKey != nil && bytes.Compare(key, endKey) >= 0 {
+					continue
+				}
+
+				keys = append(keys, key)
+				values = append(values, []byte(fmt.Sprintf("%s-val-s-%d", string(currentSrcDB), i)))
 			}
+
+			return testcommon.NewInMemoryIterator(keys, values), nil
 		},
 		GetCalled: func(key []byte) ([]byte, error) {
 			assert.Fail(t, "should have not called Get on the src DB wrapper")
@@ -150,44 +1817,81 @@ func setupForProcess(t *testing.T, test *testHandler, recorder *recorder) (Direc
 			assert.Fail(t, "should have not called Put on the src DB wrapper")
 			return nil
 		},
+		WriteBatchCalled: func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+			assert.Fail(t, "should have not called WriteBatch on the src DB wrapper")
+			return nil
+		},
 		CloseCalled: func() error {
+			recorder.mut.Lock()
 			recorder.srcClosedDBs = append(recorder.srcClosedDBs, string(currentSrcDB))
+			recorder.mut.Unlock()
 			currentSrcDB = nil
 
 			return nil
 		},
 	}
 
+	return srcDbWrapper
+}
+
+func createDestDBWrapperForProcess(t *testing.T, test *testHandler, recorder *recorder) DBWrapper {
 	var currentDestDB []byte
 	destDbWrapper := &testcommon.DBWrapperStub{
 		OpenCalled: func(path string) error {
 			currentDestDB = []byte(path)
+			recorder.mut.Lock()
 			recorder.destOpenedDBs = append(recorder.destOpenedDBs, path)
+			recorder.mut.Unlock()
 
 			return nil
 		},
-		RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
-			assert.Fail(t, "should have not called RangeKeysCalled on the dest DB wrapper")
+		NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+			keys := make([]string, 0, len(test.getOps))
+			for key := range test.getOps {
+				if strings.HasPrefix(key, string(currentDestDB)+"-") && key >= string(startKey) &&
+					(endKey == nil || key < string(endKey)) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			keysAndValues := make([]string, 0, len(keys)*2)
+			for _, key := range keys {
+				keysAndValues = append(keysAndValues, key, test.getOps[key])
+			}
+
+			return createIterator(keysAndValues...), nil
 		},
 		GetCalled: func(key []byte) ([]byte, error) {
 			val, found := test.getOps[string(key)]
 			if !found {
-				return nil, fmt.Errorf("not found")
+				return nil, leveldb.ErrNotFound
 			}
 
 			return []byte(val), nil
 		},
 		PutCalled: func(key, val []byte) error {
-			recorder.putOps[string(key)] = string(val)
+			assert.Fail(t, "should have not called Put on the dest DB wrapper")
+			return nil
+		},
+		WriteBatchCalled: func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+			recorder.mut.Lock()
+			for i := range keys {
+				recorder.putOps[string(keys[i])] = string(values[i])
+			}
+			recorder.mut.Unlock()
+
 			return nil
 		},
 		CloseCalled: func() error {
+			recorder.mut.Lock()
 			recorder.destClosedDBs = append(recorder.destClosedDBs, string(currentDestDB))
+			recorder.mut.Unlock()
 			currentDestDB = nil
 
 			return nil
 		},
 	}
 
-	return directoriesHandlerInstance, srcDbWrapper, destDbWrapper
+	return destDbWrapper
 }
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// dbIterator wraps a level DB iterator, returning copies of the keys and values as the level DB ones are
+// reused on each move
+type dbIterator struct {
+	iterator iterator.Iterator
+}
+
+func newEmptyDBIterator(err error) *dbIterator {
+	return &dbIterator{
+		iterator: iterator.NewEmptyIterator(err),
+	}
+}
+
+// Seek moves the iterator on the first key that is greater or equal to the provided key. Returns false if
+// there is no such key
+func (it *dbIterator) Seek(key []byte) bool {
+	return it.iterator.Seek(key)
+}
+
+// Next moves the iterator on the next key. Returns false if there are no more keys
+func (it *dbIterator) Next() bool {
+	return it.iterator.Next()
+}
+
+// Key returns a copy of the current key
+func (it *dbIterator) Key() []byte {
+	return cloneBytes(it.iterator.Key())
+}
+
+// Value returns a copy of the current value
+func (it *dbIterator) Value() []byte {
+	return cloneBytes(it.iterator.Value())
+}
+
+// Error returns the iteration error, if any
+func (it *dbIterator) Error() error {
+	return it.iterator.Error()
+}
+
+// Release releases the resources held by the iterator
+func (it *dbIterator) Release() {
+	it.iterator.Release()
+}
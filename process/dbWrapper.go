@@ -1,21 +1,34 @@
 package process
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
-	"github.com/multiversx/mx-chain-storage-go/leveldb"
-	"github.com/multiversx/mx-chain-storage-go/types"
+	"iulianpascalau/level-db-copy-go/common"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
-	batchDelaySeconds = 1
-	maxBatchSize      = 1000
-	maxOpenFiles      = 10
+	rwxOwner        = 0700
+	maxOpenFiles    = 10
+	currentFileName = "CURRENT"
 )
 
 type dbWrapper struct {
-	mutDB sync.RWMutex
-	db    types.Persister
+	mutDB        sync.RWMutex
+	db           *leveldb.DB
+	snapshot     *leveldb.Snapshot
+	snapshotInfo *common.SnapshotInfo
+	readOnly     bool
+	stor         storage.Storage
 }
 
 // NewDBWrapper creates a new instance of type dbWrapper
@@ -23,6 +36,15 @@ func NewDBWrapper() *dbWrapper {
 	return &dbWrapper{}
 }
 
+// NewReadOnlyDBWrapper creates a new instance of type dbWrapper that opens the DBs in read-only mode. The DB files
+// are never changed (no DB creation, no compaction, no journal or MANIFEST rewrite) and all writes are rejected.
+// All the reads are done on a snapshot taken at open, so they reflect a single point-in-time state of the DB
+func NewReadOnlyDBWrapper() *dbWrapper {
+	return &dbWrapper{
+		readOnly: true,
+	}
+}
+
 // Open will attempt to open the level DB from the provided path
 // Errors if the inner DB is still opened
 func (wrapper *dbWrapper) Open(path string) error {
@@ -33,26 +55,145 @@ func (wrapper *dbWrapper) Open(path string) error {
 		return errInnerDBIsNotClosed
 	}
 
-	lvdb, err := leveldb.NewDB(path, batchDelaySeconds, maxBatchSize, maxOpenFiles)
+	if wrapper.readOnly {
+		return wrapper.openReadOnly(path)
+	}
+
+	err := os.MkdirAll(path, rwxOwner)
 	if err != nil {
 		return err
 	}
 
+	lvdb, err := openLevelDB(path)
+	if err != nil {
+		return fmt.Errorf("%w for path %s", err, path)
+	}
+
+	wrapper.db = lvdb
+
+	return nil
+}
+
+func (wrapper *dbWrapper) openReadOnly(path string) error {
+	_, err := os.Stat(filepath.Join(path, currentFileName))
+	if err != nil {
+		return fmt.Errorf("%w, path %s: %s", errNotALevelDB, path, err.Error())
+	}
+
+	options := &opt.Options{
+		BlockCacheCapacity:     -1,
+		OpenFilesCacheCapacity: maxOpenFiles,
+		ErrorIfMissing:         true,
+		ReadOnly:               true,
+	}
+
+	stor, err := newReadOnlyStorage(path)
+	if err != nil {
+		return fmt.Errorf("%w while reading the journals for path %s", err, path)
+	}
+
+	lvdb, err := leveldb.Open(stor, options)
+	if err != nil {
+		_ = stor.Close()
+		return fmt.Errorf("%w while opening read-only for path %s", err, path)
+	}
+
+	snapshot, err := lvdb.GetSnapshot()
+	if err != nil {
+		_ = lvdb.Close()
+		_ = stor.Close()
+		return fmt.Errorf("%w while taking the snapshot for path %s", err, path)
+	}
+
 	wrapper.db = lvdb
+	wrapper.stor = stor
+	wrapper.snapshot = snapshot
+	wrapper.snapshotInfo = &common.SnapshotInfo{
+		Sequence: snapshotSequence(snapshot),
+		Time:     time.Now(),
+	}
 
 	return nil
 }
 
+// snapshotSequence extracts the sequence number of the snapshot, exposed by level DB only through its
+// string representation
+func snapshotSequence(snapshot *leveldb.Snapshot) uint64 {
+	sequence := uint64(0)
+	_, _ = fmt.Sscanf(snapshot.String(), "leveldb.Snapshot{%d}", &sequence)
+
+	return sequence
+}
+
+func openLevelDB(path string) (*leveldb.DB, error) {
+	options := &opt.Options{
+		// disable internal cache
+		BlockCacheCapacity:     -1,
+		OpenFilesCacheCapacity: maxOpenFiles,
+	}
+
+	lvdb, err := leveldb.OpenFile(path, options)
+	if err == nil || !errors.IsCorrupted(err) {
+		return lvdb, err
+	}
+
+	log.Warn("corrupted DB file, trying to recover", "path", path, "error", err)
+
+	return leveldb.RecoverFile(path, options)
+}
+
 // RangeKeys will call the provided handler for each key and value found in the storage
 func (wrapper *dbWrapper) RangeKeys(handler func(key []byte, val []byte) bool) {
+	iterator, err := wrapper.NewIterator(nil, nil)
+	if err != nil {
+		return
+	}
+	defer iterator.Release()
+
+	for iterator.Next() {
+		shouldContinue := handler(iterator.Key(), iterator.Value())
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// NewIterator returns an iterator over the keys from the [startKey, endKey) range, seeking directly to the start
+// key. A nil start key means the first key and a nil end key means past the last key. The iterator must be
+// released before closing the DB
+func (wrapper *dbWrapper) NewIterator(startKey []byte, endKey []byte) (common.DBIterator, error) {
 	wrapper.mutDB.RLock()
 	defer wrapper.mutDB.RUnlock()
 
 	if wrapper.db == nil {
-		return
+		return nil, errInnerDBIsNotOpened
+	}
+
+	keyRange := &util.Range{Start: startKey, Limit: endKey}
+	if wrapper.snapshot != nil {
+		return &dbIterator{
+			iterator: wrapper.snapshot.NewIterator(keyRange, nil),
+		}, nil
 	}
 
-	wrapper.db.RangeKeys(handler)
+	return &dbIterator{
+		iterator: wrapper.db.NewIterator(keyRange, nil),
+	}, nil
+}
+
+// SnapshotInfo returns the information about the snapshot the reads are done on, nil if the live DB is read
+func (wrapper *dbWrapper) SnapshotInfo() *common.SnapshotInfo {
+	wrapper.mutDB.RLock()
+	defer wrapper.mutDB.RUnlock()
+
+	return wrapper.snapshotInfo
+}
+
+func cloneBytes(data []byte) []byte {
+	cloned := make([]byte, len(data))
+	copy(cloned, data)
+
+	return cloned
 }
 
 // Get gets the value associated to the key
@@ -63,8 +204,11 @@ func (wrapper *dbWrapper) Get(key []byte) ([]byte, error) {
 	if wrapper.db == nil {
 		return nil, errInnerDBIsNotOpened
 	}
+	if wrapper.snapshot != nil {
+		return wrapper.snapshot.Get(key, nil)
+	}
 
-	return wrapper.db.Get(key)
+	return wrapper.db.Get(key, nil)
 }
 
 // Put add the value to the (key, val) persistence medium
@@ -75,8 +219,54 @@ func (wrapper *dbWrapper) Put(key, val []byte) error {
 	if wrapper.db == nil {
 		return errInnerDBIsNotOpened
 	}
+	if wrapper.readOnly {
+		return errReadOnlyDB
+	}
+
+	return wrapper.db.Put(key, val, nil)
+}
+
+// Remove deletes the key from the (key, val) persistence medium. Removing a missing key is not an error
+func (wrapper *dbWrapper) Remove(key []byte) error {
+	wrapper.mutDB.RLock()
+	defer wrapper.mutDB.RUnlock()
+
+	if wrapper.db == nil {
+		return errInnerDBIsNotOpened
+	}
+	if wrapper.readOnly {
+		return errReadOnlyDB
+	}
 
-	return wrapper.db.Put(key, val)
+	return wrapper.db.Delete(key, nil)
+}
+
+// WriteBatch writes all the provided (key, val) pairs and removes all the provided keys in a single atomic write. If
+// the sync flag is set, the write is flushed to the disk before returning
+func (wrapper *dbWrapper) WriteBatch(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("%w, %d keys and %d values", errKeysValuesLengthMismatch, len(keys), len(values))
+	}
+
+	wrapper.mutDB.RLock()
+	defer wrapper.mutDB.RUnlock()
+
+	if wrapper.db == nil {
+		return errInnerDBIsNotOpened
+	}
+	if wrapper.readOnly {
+		return errReadOnlyDB
+	}
+
+	batch := new(leveldb.Batch)
+	for i := range keys {
+		batch.Put(keys[i], values[i])
+	}
+	for _, key := range removedKeys {
+		batch.Delete(key)
+	}
+
+	return wrapper.db.Write(batch, &opt.WriteOptions{Sync: sync})
 }
 
 // Close closes the files/resources associated to the persistence medium
@@ -84,8 +274,25 @@ func (wrapper *dbWrapper) Close() error {
 	wrapper.mutDB.Lock()
 	defer wrapper.mutDB.Unlock()
 
+	if wrapper.db == nil {
+		return errInnerDBIsNotOpened
+	}
+
+	if wrapper.snapshot != nil {
+		wrapper.snapshot.Release()
+		wrapper.snapshot = nil
+		wrapper.snapshotInfo = nil
+	}
+
 	err := wrapper.db.Close()
 	wrapper.db = nil
+	if wrapper.stor != nil {
+		errStor := wrapper.stor.Close()
+		if err == nil {
+			err = errStor
+		}
+		wrapper.stor = nil
+	}
 
 	return err
 }
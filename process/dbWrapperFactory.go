@@ -0,0 +1,49 @@
+package process
+
+// DBRole defines the role a DB has in the copy process
+type DBRole int
+
+const (
+	// SourceRole is the role of a DB the data is read from
+	SourceRole DBRole = iota
+	// DestinationRole is the role of a DB the data is written to
+	DestinationRole
+	// WritableSourceRole is the role of a source DB that also receives the data missing from it (bidirectional mode)
+	WritableSourceRole
+)
+
+// String returns the human-readable form of the role
+func (role DBRole) String() string {
+	switch role {
+	case SourceRole:
+		return "source"
+	case DestinationRole:
+		return "destination"
+	case WritableSourceRole:
+		return "writable source"
+	default:
+		return "unknown"
+	}
+}
+
+type dbWrapperFactory struct{}
+
+// NewDBWrapperFactory creates a new instance of type dbWrapperFactory
+func NewDBWrapperFactory() *dbWrapperFactory {
+	return &dbWrapperFactory{}
+}
+
+// Create creates a new level DB wrapper. The source DBs are opened in read-only mode, unless they are written in the
+// bidirectional mode
+func (factory *dbWrapperFactory) Create(_ string, role DBRole) (DBWrapper, error) {
+	if role == SourceRole {
+		return NewReadOnlyDBWrapper(), nil
+	}
+
+	return NewDBWrapper(), nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (factory *dbWrapperFactory) IsInterfaceNil() bool {
+	return factory == nil
+}
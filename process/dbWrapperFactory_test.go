@@ -0,0 +1,64 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbWrapperFactoryStub struct {
+	createCalled func(path string, role DBRole) (DBWrapper, error)
+}
+
+// Create -
+func (stub *dbWrapperFactoryStub) Create(path string, role DBRole) (DBWrapper, error) {
+	if stub.createCalled != nil {
+		return stub.createCalled(path, role)
+	}
+
+	return NewDBWrapper(), nil
+}
+
+// IsInterfaceNil -
+func (stub *dbWrapperFactoryStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestDBRole_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "source", SourceRole.String())
+	assert.Equal(t, "destination", DestinationRole.String())
+	assert.Equal(t, "writable source", WritableSourceRole.String())
+	assert.Equal(t, "unknown", DBRole(100).String())
+}
+
+func TestDbWrapperFactory_Create(t *testing.T) {
+	t.Parallel()
+
+	factory := NewDBWrapperFactory()
+	assert.False(t, check.IfNil(factory))
+
+	wrapper, err := factory.Create(t.TempDir(), SourceRole)
+	assert.Nil(t, err)
+	assert.True(t, wrapper.(*dbWrapper).readOnly)
+
+	wrapper, err = factory.Create(t.TempDir(), DestinationRole)
+	assert.Nil(t, err)
+	assert.False(t, wrapper.(*dbWrapper).readOnly)
+
+	wrapper, err = factory.Create(t.TempDir(), WritableSourceRole)
+	assert.Nil(t, err)
+	assert.False(t, wrapper.(*dbWrapper).readOnly)
+}
+
+func TestDbWrapperFactory_IsInterfaceNil(t *testing.T) {
+	t.Parallel()
+
+	var instance *dbWrapperFactory
+	assert.True(t, instance.IsInterfaceNil())
+
+	instance = &dbWrapperFactory{}
+	assert.False(t, instance.IsInterfaceNil())
+}
@@ -1,10 +1,15 @@
 package process
 
 import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 func TestNewDBWrapper(t *testing.T) {
@@ -83,9 +88,6 @@ func TestDbWrapper_GetPutClose(t *testing.T) {
 		err = wrapper.Put([]byte("key2"), []byte("value2"))
 		assert.Nil(t, err)
 
-		// we need this sleep to put the data inside the files
-		time.Sleep(time.Second * 3)
-
 		recoveredValue, err := wrapper.Get([]byte("key1"))
 		assert.Nil(t, err)
 		assert.Equal(t, "value1", string(recoveredValue))
@@ -99,6 +101,59 @@ func TestDbWrapper_GetPutClose(t *testing.T) {
 	})
 }
 
+func TestDbWrapper_Remove(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Remove in an unopened DB should error", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := NewDBWrapper()
+		err := wrapper.Remove([]byte("key1"))
+		assert.Equal(t, errInnerDBIsNotOpened, err)
+	})
+	t.Run("Remove in a read-only DB should error", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writableWrapper := NewDBWrapper()
+		_ = writableWrapper.Open(dir)
+		_ = writableWrapper.Put([]byte("key1"), []byte("value1"))
+		_ = writableWrapper.Close()
+
+		wrapper := NewReadOnlyDBWrapper()
+		_ = wrapper.Open(dir)
+		err := wrapper.Remove([]byte("key1"))
+		assert.Equal(t, errReadOnlyDB, err)
+
+		value, _ := wrapper.Get([]byte("key1"))
+		assert.Equal(t, []byte("value1"), value)
+		_ = wrapper.Close()
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := NewDBWrapper()
+		_ = wrapper.Open(t.TempDir())
+		_ = wrapper.Put([]byte("key1"), []byte("value1"))
+		_ = wrapper.Put([]byte("key2"), []byte("value2"))
+
+		err := wrapper.Remove([]byte("key1"))
+		assert.Nil(t, err)
+
+		err = wrapper.Remove([]byte("missing key"))
+		assert.Nil(t, err)
+
+		_, err = wrapper.Get([]byte("key1"))
+		assert.NotNil(t, err)
+
+		value, err := wrapper.Get([]byte("key2"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value2"), value)
+
+		_ = wrapper.Close()
+	})
+}
+
 func TestDbWrapper_PutRangeKeys(t *testing.T) {
 	t.Parallel()
 
@@ -127,8 +182,6 @@ func TestDbWrapper_PutRangeKeys(t *testing.T) {
 		err = wrapper.Put([]byte("key2"), []byte("value2"))
 		assert.Nil(t, err)
 
-		time.Sleep(time.Second * 3)
-
 		rangeKeys := make(map[string]string, 2)
 		wrapper.RangeKeys(func(key []byte, val []byte) bool {
 			rangeKeys[string(key)] = string(val)
@@ -144,3 +197,311 @@ func TestDbWrapper_PutRangeKeys(t *testing.T) {
 		_ = wrapper.Close()
 	})
 }
+
+func TestDbWrapper_NewIterator(t *testing.T) {
+	t.Parallel()
+
+	wrapper := NewDBWrapper()
+	iterator, err := wrapper.NewIterator(nil, nil)
+	assert.Nil(t, iterator)
+	assert.Equal(t, errInnerDBIsNotOpened, err)
+
+	_ = wrapper.Open(t.TempDir())
+	defer func() {
+		_ = wrapper.Close()
+	}()
+
+	for _, key := range []string{"key1", "key2", "key3", "key4"} {
+		err = wrapper.Put([]byte(key), []byte("value-"+key))
+		assert.Nil(t, err)
+	}
+
+	iterateKeys := func(startKey []byte, endKey []byte) []string {
+		iterator, errIterator := wrapper.NewIterator(startKey, endKey)
+		require.Nil(t, errIterator)
+		defer iterator.Release()
+
+		keys := make([]string, 0)
+		for iterator.Next() {
+			keys = append(keys, string(iterator.Key()))
+			assert.Equal(t, "value-"+string(iterator.Key()), string(iterator.Value()))
+		}
+		assert.Nil(t, iterator.Error())
+
+		return keys
+	}
+
+	t.Run("should iterate all the keys", func(t *testing.T) {
+		assert.Equal(t, []string{"key1", "key2", "key3", "key4"}, iterateKeys(nil, nil))
+	})
+	t.Run("should start from the provided key", func(t *testing.T) {
+		assert.Equal(t, []string{"key2", "key3", "key4"}, iterateKeys([]byte("key2"), nil))
+	})
+	t.Run("should start from the next existing key", func(t *testing.T) {
+		assert.Equal(t, []string{"key3", "key4"}, iterateKeys([]byte("key20"), nil))
+	})
+	t.Run("should stop before the end key", func(t *testing.T) {
+		assert.Equal(t, []string{"key2", "key3"}, iterateKeys([]byte("key2"), []byte("key4")))
+	})
+	t.Run("seek should move on the first key greater or equal", func(t *testing.T) {
+		iterator, errIterator := wrapper.NewIterator(nil, []byte("key4"))
+		require.Nil(t, errIterator)
+		defer iterator.Release()
+
+		assert.True(t, iterator.Seek([]byte("key20")))
+		assert.Equal(t, "key3", string(iterator.Key()))
+		assert.False(t, iterator.Next())
+		assert.False(t, iterator.Seek([]byte("key5")))
+	})
+	t.Run("returned key should not change when moving the iterator", func(t *testing.T) {
+		iterator, errIterator := wrapper.NewIterator(nil, nil)
+		require.Nil(t, errIterator)
+		defer iterator.Release()
+
+		assert.True(t, iterator.Next())
+		key := iterator.Key()
+		assert.True(t, iterator.Next())
+		assert.Equal(t, "key1", string(key))
+	})
+}
+
+func TestDbWrapper_CloseUnopenedDBShouldError(t *testing.T) {
+	t.Parallel()
+
+	wrapper := NewDBWrapper()
+	err := wrapper.Close()
+	assert.Equal(t, errInnerDBIsNotOpened, err)
+}
+
+func TestDbWrapper_WriteBatch(t *testing.T) {
+	t.Parallel()
+
+	wrapper := NewDBWrapper()
+	keys := [][]byte{[]byte("key1"), []byte("key2")}
+	values := [][]byte{[]byte("value1"), []byte("value2")}
+
+	t.Run("WriteBatch in an unopened DB should error", func(t *testing.T) {
+		err := wrapper.WriteBatch(keys, values, nil, false)
+		assert.Equal(t, errInnerDBIsNotOpened, err)
+	})
+	t.Run("keys and values length mismatch should error", func(t *testing.T) {
+		err := wrapper.WriteBatch(keys, values[:1], nil, false)
+		assert.ErrorIs(t, err, errKeysValuesLengthMismatch)
+	})
+	t.Run("should work", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = wrapper.Open(dir)
+
+		err := wrapper.WriteBatch(keys, values, nil, true)
+		assert.Nil(t, err)
+		_ = wrapper.Close()
+
+		_ = wrapper.Open(dir)
+		for i := range keys {
+			recoveredValue, errGet := wrapper.Get(keys[i])
+			assert.Nil(t, errGet)
+			assert.Equal(t, values[i], recoveredValue)
+		}
+		_ = wrapper.Close()
+	})
+	t.Run("should write and remove in the same batch", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = wrapper.Open(dir)
+		_ = wrapper.Put([]byte("key3"), []byte("value3"))
+
+		err := wrapper.WriteBatch(keys, values, [][]byte{[]byte("key3"), []byte("missing key")}, false)
+		assert.Nil(t, err)
+
+		for i := range keys {
+			recoveredValue, errGet := wrapper.Get(keys[i])
+			assert.Nil(t, errGet)
+			assert.Equal(t, values[i], recoveredValue)
+		}
+		_, err = wrapper.Get([]byte("key3"))
+		assert.Equal(t, leveldb.ErrNotFound, err)
+		_ = wrapper.Close()
+	})
+}
+
+func TestDbWrapper_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing directory should error and not create it", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "missing")
+		wrapper := NewReadOnlyDBWrapper()
+		err := wrapper.Open(dir)
+		assert.ErrorIs(t, err, errNotALevelDB)
+		assert.Contains(t, err.Error(), dir)
+
+		_, err = os.Stat(dir)
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("directory that is not a DB should error and remain empty", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		wrapper := NewReadOnlyDBWrapper()
+		err := wrapper.Open(dir)
+		assert.ErrorIs(t, err, errNotALevelDB)
+
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+	t.Run("should read an existing DB and reject writes", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writableWrapper := NewDBWrapper()
+		_ = writableWrapper.Open(dir)
+		_ = writableWrapper.Put([]byte("key1"), []byte("value1"))
+		_ = writableWrapper.Close()
+
+		wrapper := NewReadOnlyDBWrapper()
+		err := wrapper.Open(dir)
+		require.Nil(t, err)
+
+		value, err := wrapper.Get([]byte("key1"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value1"), value)
+
+		err = wrapper.Put([]byte("key2"), []byte("value2"))
+		assert.Equal(t, errReadOnlyDB, err)
+
+		err = wrapper.WriteBatch([][]byte{[]byte("key2")}, [][]byte{[]byte("value2")}, nil, false)
+		assert.Equal(t, errReadOnlyDB, err)
+
+		err = wrapper.Close()
+		assert.Nil(t, err)
+	})
+}
+
+func readDirContent(t *testing.T, dir string) map[string][]byte {
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+
+	content := make(map[string][]byte)
+	for _, entry := range entries {
+		data, errRead := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.Nil(t, errRead)
+		content[entry.Name()] = data
+	}
+
+	return content
+}
+
+func TestDbWrapper_ReadOnlyWithRotatedJournals(t *testing.T) {
+	t.Parallel()
+
+	// random keys and values, written in batches, fill more than the 4 MiB write buffer so the DB is closed with
+	// more than one journal file
+	numKeys := 100000
+	keys := make([][]byte, 0, numKeys)
+	values := make([][]byte, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		value := make([]byte, 100)
+		_, _ = rand.Read(value)
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	dir := t.TempDir()
+	writableWrapper := NewDBWrapper()
+	require.Nil(t, writableWrapper.Open(dir))
+	for i := 0; i < numKeys; i += 1000 {
+		require.Nil(t, writableWrapper.WriteBatch(keys[i:i+1000], values[i:i+1000], nil, false))
+	}
+	require.Nil(t, writableWrapper.Close())
+
+	journals, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	require.GreaterOrEqual(t, len(journals), 2)
+	contentBefore := readDirContent(t, dir)
+
+	wrapper := NewReadOnlyDBWrapper()
+	require.Nil(t, wrapper.Open(dir))
+
+	for i := range keys {
+		value, err := wrapper.Get(keys[i])
+		require.Nil(t, err)
+		require.Equal(t, values[i], value)
+	}
+	numRead := 0
+	wrapper.RangeKeys(func(key []byte, val []byte) bool {
+		numRead++
+		return true
+	})
+	assert.Equal(t, numKeys, numRead)
+	assert.Nil(t, wrapper.Close())
+
+	assert.Equal(t, contentBefore, readDirContent(t, dir))
+}
+
+func TestDbWrapper_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writable DB should not have a snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := NewDBWrapper()
+		_ = wrapper.Open(t.TempDir())
+		defer func() {
+			_ = wrapper.Close()
+		}()
+
+		assert.Nil(t, wrapper.SnapshotInfo())
+	})
+	t.Run("read-only DB should record the snapshot taken at open", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writableWrapper := NewDBWrapper()
+		_ = writableWrapper.Open(dir)
+		_ = writableWrapper.Put([]byte("key1"), []byte("value1"))
+		_ = writableWrapper.Put([]byte("key2"), []byte("value2"))
+		_ = writableWrapper.Close()
+
+		wrapper := NewReadOnlyDBWrapper()
+		before := time.Now()
+		err := wrapper.Open(dir)
+		require.Nil(t, err)
+
+		info := wrapper.SnapshotInfo()
+		require.NotNil(t, info)
+		assert.GreaterOrEqual(t, info.Sequence, uint64(2))
+		assert.False(t, info.Time.Before(before))
+
+		_ = wrapper.Close()
+		assert.Nil(t, wrapper.SnapshotInfo())
+	})
+	t.Run("reads should not see the writes done after the snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		wrapper := NewDBWrapper()
+		_ = wrapper.Open(t.TempDir())
+		_ = wrapper.Put([]byte("key1"), []byte("value1"))
+
+		lvdb := wrapper.db
+		wrapper.snapshot, _ = lvdb.GetSnapshot()
+		_ = lvdb.Put([]byte("key1"), []byte("changed"), nil)
+		_ = lvdb.Put([]byte("key2"), []byte("value2"), nil)
+
+		value, err := wrapper.Get([]byte("key1"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value1"), value)
+
+		_, err = wrapper.Get([]byte("key2"))
+		assert.NotNil(t, err)
+
+		keys := make([]string, 0)
+		wrapper.RangeKeys(func(key []byte, val []byte) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		assert.Equal(t, []string{"key1"}, keys)
+
+		_ = wrapper.Close()
+	})
+}
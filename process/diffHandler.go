@@ -0,0 +1,177 @@
+package process
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+)
+
+// ArgsDiffHandler is the DTO used to create a new instance of type diff handler
+type ArgsDiffHandler struct {
+	DirectoriesHandler DirectoriesHandler
+	DBWrapperFactory   DBWrapperFactory
+	KeyRange           KeyRange
+	MaxListedKeys      int
+}
+
+type diffHandler struct {
+	directoriesHandler DirectoriesHandler
+	dbWrapperFactory   DBWrapperFactory
+	keyRange           KeyRange
+	maxListedKeys      int
+}
+
+// NewDiffHandler creates a new instance of type diff handler
+func NewDiffHandler(args ArgsDiffHandler) (*diffHandler, error) {
+	if check.IfNil(args.DirectoriesHandler) {
+		return nil, errNilDirectoriesHandler
+	}
+	if len(args.DirectoriesHandler.DestinationParentDirectories()) == 0 {
+		return nil, errNoDestinationParentDirectory
+	}
+	if check.IfNil(args.DBWrapperFactory) {
+		return nil, errNilDBWrapperFactory
+	}
+	if args.MaxListedKeys < 0 {
+		return nil, fmt.Errorf("%w, provided %d", errInvalidMaxListedKeys, args.MaxListedKeys)
+	}
+
+	return &diffHandler{
+		directoriesHandler: args.DirectoriesHandler,
+		dbWrapperFactory:   args.DBWrapperFactory,
+		keyRange:           args.KeyRange,
+		maxListedKeys:      args.MaxListedKeys,
+	}, nil
+}
+
+// Process compares each pair of source and destination DBs in a single ordered pass. Both sides are opened in
+// read-only mode so nothing is ever changed. At most the configured number of keys is listed for each category.
+// A DB found in several destination parent directories is compared with each of them
+func (handler *diffHandler) Process() (*DiffReport, error) {
+	selection, err := selectDirectories(handler.directoriesHandler, false)
+	if err != nil {
+		return nil, err
+	}
+	logSelection(selection, false)
+
+	report := &DiffReport{
+		DBs:                make([]*DBDiff, 0, len(selection.names)),
+		SourceOnlyDBs:      selection.srcOnly,
+		DestinationOnlyDBs: selection.destOnly,
+		ExcludedDBs:        selection.excluded,
+	}
+	for index, name := range selection.names {
+		log.Info("now comparing sub-directory", "name", name, "overall progress", fmt.Sprintf("%d/%d", index+1, len(selection.names)))
+
+		pathInfo := selection.dirs[name]
+		for _, dest := range pathInfo.allDests() {
+			dbDiff, errDiff := handler.diffDB(name, pathInfo, dest.path)
+			if errDiff != nil {
+				return nil, errDiff
+			}
+
+			log.Info("compared DB", "name", name, "dest path", dest.path, "only in source", dbDiff.OnlyInSource,
+				"only in destination", dbDiff.OnlyInDestination, "different", dbDiff.Different)
+			report.DBs = append(report.DBs, dbDiff)
+			report.Total.add(dbDiff.DiffCounters)
+		}
+	}
+
+	return report, nil
+}
+
+func (handler *diffHandler) diffDB(name string, pathInfo paths, destPath string) (*DBDiff, error) {
+	srcDBWrapper, err := openReadOnly(handler.dbWrapperFactory, pathInfo.src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = srcDBWrapper.Close()
+	}()
+
+	extraSrcDBWrappers, err := openExtraSources(handler.dbWrapperFactory, name, pathInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = closeAll(extraSrcDBWrappers)
+	}()
+
+	destDBWrapper, err := openReadOnly(handler.dbWrapperFactory, destPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = destDBWrapper.Close()
+	}()
+
+	srcDBWrappers := append([]DBWrapper{srcDBWrapper}, extraSrcDBWrappers...)
+	srcIterator, err := newSourcesIterator(srcDBWrappers, handler.keyRange.Start, handler.keyRange.End, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer srcIterator.Release()
+
+	destIterator, err := destDBWrapper.NewIterator(handler.keyRange.Start, handler.keyRange.End)
+	if err != nil {
+		return nil, err
+	}
+	defer destIterator.Release()
+
+	dbDiff := &DBDiff{
+		Name:                name,
+		SourcePath:          pathInfo.src,
+		DestinationPath:     destPath,
+		ExtraSourcePaths:    pathInfo.extraSrcs,
+		SourceSnapshot:      srcDBWrapper.SnapshotInfo(),
+		DestinationSnapshot: destDBWrapper.SnapshotInfo(),
+	}
+	err = walkMerged(srcIterator, destIterator, func(entry *mergedEntry) bool {
+		handler.accountEntry(dbDiff, entry)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w while comparing %s with %s", err, pathInfo.src, destPath)
+	}
+
+	return dbDiff, nil
+}
+
+func (handler *diffHandler) accountEntry(dbDiff *DBDiff, entry *mergedEntry) {
+	switch {
+	case !entry.inDest:
+		dbDiff.OnlyInSource++
+		dbDiff.OnlyInSourceKeys = handler.appendKey(dbDiff.OnlyInSourceKeys, entry.key)
+	case !entry.inSrc:
+		dbDiff.OnlyInDestination++
+		dbDiff.OnlyInDestinationKeys = handler.appendKey(dbDiff.OnlyInDestinationKeys, entry.key)
+	case !bytes.Equal(entry.srcValue, entry.destValue):
+		dbDiff.Different++
+		dbDiff.DifferentKeys = handler.appendKey(dbDiff.DifferentKeys, entry.key)
+	}
+}
+
+func (handler *diffHandler) appendKey(keys []string, key []byte) []string {
+	if len(keys) >= handler.maxListedKeys {
+		return keys
+	}
+
+	return append(keys, hex.EncodeToString(key))
+}
+
+// openReadOnly creates and opens a DB wrapper in read-only mode, using the source role
+func openReadOnly(factory DBWrapperFactory, path string) (DBWrapper, error) {
+	wrapper, err := factory.Create(path, SourceRole)
+	if err != nil {
+		return nil, err
+	}
+
+	err = wrapper.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapper, nil
+}
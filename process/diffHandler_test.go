@@ -0,0 +1,294 @@
+package process
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/common"
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createMockArgsDiffHandler() ArgsDiffHandler {
+	return ArgsDiffHandler{
+		DirectoriesHandler: &testcommon.DirectoriesHandlerStub{},
+		DBWrapperFactory:   &dbWrapperFactoryStub{},
+		MaxListedKeys:      10,
+	}
+}
+
+func createDBsForDiff(t *testing.T, contents map[string][]string, rec *recorder) DBWrapperFactory {
+	return &dbWrapperFactoryStub{
+		createCalled: func(path string, role DBRole) (DBWrapper, error) {
+			assert.Equal(t, SourceRole, role, "all the DBs should be opened in read-only mode")
+
+			return &testcommon.DBWrapperStub{
+				OpenCalled: func(path string) error {
+					rec.mut.Lock()
+					rec.srcOpenedDBs = append(rec.srcOpenedDBs, path)
+					rec.mut.Unlock()
+
+					return nil
+				},
+				NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+					return createIterator(contents[path]...), nil
+				},
+				PutCalled: func(key, val []byte) error {
+					assert.Fail(t, "should have not called Put")
+					return nil
+				},
+				WriteBatchCalled: func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+					assert.Fail(t, "should have not called WriteBatch")
+					return nil
+				},
+				CloseCalled: func() error {
+					rec.mut.Lock()
+					rec.srcClosedDBs = append(rec.srcClosedDBs, path)
+					rec.mut.Unlock()
+
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+func TestNewDiffHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil directories handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = nil
+		handler, err := NewDiffHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDirectoriesHandler, err)
+	})
+	t.Run("no destination parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			DestinationParentDirectoriesCalled: func() []string {
+				return make([]string, 0)
+			},
+		}
+		handler, err := NewDiffHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNoDestinationParentDirectory, err)
+	})
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDiffHandler()
+		args.DBWrapperFactory = nil
+		handler, err := NewDiffHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("invalid maximum number of listed keys should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDiffHandler()
+		args.MaxListedKeys = -1
+		handler, err := NewDiffHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidMaxListedKeys)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDiffHandler(createMockArgsDiffHandler())
+
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+}
+
+func TestDiffHandler_Process(t *testing.T) {
+	t.Parallel()
+
+	contents := map[string][]string{
+		"src/A":  {"a1", "v1", "a2", "v2", "a3", "v3", "a4", "v4"},
+		"dest/A": {"a0", "v0", "a2", "v2", "a3", "x3", "a4", "x4"},
+		"src/B":  {"b1", "v1"},
+		"dest/B": {"b1", "v1"},
+	}
+	directoriesHandler := &testcommon.DirectoriesHandlerStub{
+		SourceParentDirectoriesCalled: func() []string {
+			return []string{"src"}
+		},
+		DestinationParentDirectoriesCalled: func() []string {
+			return []string{"dest"}
+		},
+		SourceDirectoriesCalled: func(parentDir string) []string {
+			return []string{"src/A", "src/B", "src/C"}
+		},
+		DestinationDirectoriesCalled: func(parentDir string) []string {
+			return []string{"dest/A", "dest/B", "dest/D"}
+		},
+	}
+
+	t.Run("should report the differences", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		args.MaxListedKeys = 1
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, err)
+
+		expectedReport := &DiffReport{
+			DBs: []*DBDiff{
+				{
+					Name:                  "A",
+					SourcePath:            "src/A",
+					DestinationPath:       "dest/A",
+					DiffCounters:          DiffCounters{OnlyInSource: 1, OnlyInDestination: 1, Different: 2},
+					OnlyInSourceKeys:      []string{hex.EncodeToString([]byte("a1"))},
+					OnlyInDestinationKeys: []string{hex.EncodeToString([]byte("a0"))},
+					DifferentKeys:         []string{hex.EncodeToString([]byte("a3"))},
+				},
+				{
+					Name:            "B",
+					SourcePath:      "src/B",
+					DestinationPath: "dest/B",
+				},
+			},
+			SourceOnlyDBs:      []string{"C"},
+			DestinationOnlyDBs: []string{"D"},
+			ExcludedDBs:        make([]string, 0),
+			Total:              DiffCounters{OnlyInSource: 1, OnlyInDestination: 1, Different: 2},
+		}
+		assert.Equal(t, expectedReport, report)
+		assert.ElementsMatch(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+		assert.Equal(t, 4, len(rec.srcOpenedDBs))
+	})
+	t.Run("should compare the source DB with each destination DB", func(t *testing.T) {
+		t.Parallel()
+
+		multiDestContents := map[string][]string{
+			"src/A":   {"a1", "v1", "a2", "v2"},
+			"dest1/A": {"a1", "v1"},
+			"dest2/A": {"a1", "v1", "a2", "x2"},
+		}
+		rec := &recorder{}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			SourceParentDirectoriesCalled: func() []string {
+				return []string{"src"}
+			},
+			SourceDirectoriesCalled: func(parentDir string) []string {
+				return []string{"src/A"}
+			},
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"dest1", "dest2"}
+			},
+			DestinationDirectoriesCalled: func(parentDir string) []string {
+				return []string{parentDir + "/A"}
+			},
+		}
+		args.DBWrapperFactory = createDBsForDiff(t, multiDestContents, rec)
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, err)
+		require.Len(t, report.DBs, 2)
+		assert.Equal(t, "dest1/A", report.DBs[0].DestinationPath)
+		assert.Equal(t, DiffCounters{OnlyInSource: 1}, report.DBs[0].DiffCounters)
+		assert.Equal(t, "dest2/A", report.DBs[1].DestinationPath)
+		assert.Equal(t, DiffCounters{Different: 1}, report.DBs[1].DiffCounters)
+		assert.Equal(t, DiffCounters{OnlyInSource: 1, Different: 1}, report.Total)
+	})
+	t.Run("no listed keys should only count", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		args.MaxListedKeys = 0
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, err)
+		assert.Equal(t, DiffCounters{OnlyInSource: 1, OnlyInDestination: 1, Different: 2}, report.Total)
+		assert.Empty(t, report.DBs[0].OnlyInSourceKeys)
+		assert.Empty(t, report.DBs[0].OnlyInDestinationKeys)
+		assert.Empty(t, report.DBs[0].DifferentKeys)
+	})
+	t.Run("should pass the key range to the iterators", func(t *testing.T) {
+		t.Parallel()
+
+		keyRange := KeyRange{Start: []byte("a"), End: []byte("b")}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.KeyRange = keyRange
+		args.DBWrapperFactory = &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				return &testcommon.DBWrapperStub{
+					NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+						assert.Equal(t, keyRange.Start, startKey)
+						assert.Equal(t, keyRange.End, endKey)
+
+						return createIterator(), nil
+					},
+				}, nil
+			},
+		}
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, err)
+		assert.False(t, report.Total.HasDifferences())
+	})
+	t.Run("iteration error should error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		rec := &recorder{}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = wrapFactory(createDBsForDiff(t, contents, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.NewIteratorCalled = func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return testcommon.NewInMemoryIteratorWithError(expectedErr), nil
+			}
+		})
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, report)
+		assert.ErrorIs(t, err, expectedErr)
+		assert.ElementsMatch(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+	})
+	t.Run("open error should error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		rec := &recorder{}
+		args := createMockArgsDiffHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = wrapFactory(createDBsForDiff(t, contents, rec), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.OpenCalled = func(path string) error {
+				return expectedErr
+			}
+		})
+		handler, _ := NewDiffHandler(args)
+
+		report, err := handler.Process()
+		assert.Nil(t, report)
+		assert.Equal(t, expectedErr, err)
+	})
+}
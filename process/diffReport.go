@@ -0,0 +1,154 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"iulianpascalau/level-db-copy-go/common"
+)
+
+const (
+	// TextOutputFormat is the human-readable report format
+	TextOutputFormat = "text"
+	// JSONOutputFormat is the JSON report format
+	JSONOutputFormat = "json"
+)
+
+// OutputFormats returns all the available report formats
+func OutputFormats() []string {
+	return []string{TextOutputFormat, JSONOutputFormat}
+}
+
+// CheckOutputFormat returns an error if the provided report format is not available
+func CheckOutputFormat(format string) error {
+	for _, availableFormat := range OutputFormats() {
+		if format == availableFormat {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, available formats: %s", errUnknownOutputFormat, format, strings.Join(OutputFormats(), ", "))
+}
+
+// DiffCounters holds the number of keys that differ between a source and a destination DB
+type DiffCounters struct {
+	OnlyInSource      int `json:"onlyInSource"`
+	OnlyInDestination int `json:"onlyInDestination"`
+	Different         int `json:"different"`
+}
+
+// HasDifferences returns true if at least one difference was counted
+func (counters DiffCounters) HasDifferences() bool {
+	return counters.OnlyInSource+counters.OnlyInDestination+counters.Different > 0
+}
+
+func (counters *DiffCounters) add(other DiffCounters) {
+	counters.OnlyInSource += other.OnlyInSource
+	counters.OnlyInDestination += other.OnlyInDestination
+	counters.Different += other.Different
+}
+
+// DBDiff holds the differences between a source and a destination DB. The listed keys are hex encoded. When the DB
+// is read from several source parent directories, the source keys are merged in priority order
+type DBDiff struct {
+	Name                string               `json:"name"`
+	SourcePath          string               `json:"sourcePath"`
+	DestinationPath     string               `json:"destinationPath"`
+	ExtraSourcePaths    []string             `json:"extraSourcePaths,omitempty"`
+	SourceSnapshot      *common.SnapshotInfo `json:"sourceSnapshot,omitempty"`
+	DestinationSnapshot *common.SnapshotInfo `json:"destinationSnapshot,omitempty"`
+	DiffCounters
+	OnlyInSourceKeys      []string `json:"onlyInSourceKeys,omitempty"`
+	OnlyInDestinationKeys []string `json:"onlyInDestinationKeys,omitempty"`
+	DifferentKeys         []string `json:"differentKeys,omitempty"`
+	Verification          string   `json:"verification,omitempty"`
+}
+
+// DiffReport holds the differences between the source and destination trees
+type DiffReport struct {
+	DBs                []*DBDiff    `json:"dbs"`
+	SourceOnlyDBs      []string     `json:"sourceOnlyDBs"`
+	DestinationOnlyDBs []string     `json:"destinationOnlyDBs"`
+	ExcludedDBs        []string     `json:"excludedDBs"`
+	Total              DiffCounters `json:"total"`
+	Verification       string       `json:"verification,omitempty"`
+}
+
+// WriteDiffReport writes the report in the provided format
+func WriteDiffReport(writer io.Writer, report *DiffReport, format string) error {
+	switch format {
+	case JSONOutputFormat:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case TextOutputFormat:
+		return writeDiffReportAsText(writer, report)
+	default:
+		return CheckOutputFormat(format)
+	}
+}
+
+func writeDiffReportAsText(writer io.Writer, report *DiffReport) error {
+	builder := &strings.Builder{}
+	for _, dbDiff := range report.DBs {
+		status := "identical"
+		if dbDiff.HasDifferences() {
+			status = "different"
+		}
+		if len(dbDiff.Verification) > 0 {
+			status = "verification " + dbDiff.Verification
+		}
+
+		_, _ = fmt.Fprintf(builder, "DB %s: %s\n", dbDiff.Name, status)
+		_, _ = fmt.Fprintf(builder, "  source:      %s\n", dbDiff.SourcePath)
+		if len(dbDiff.ExtraSourcePaths) > 0 {
+			_, _ = fmt.Fprintf(builder, "  extra sources: %s\n", strings.Join(dbDiff.ExtraSourcePaths, ", "))
+		}
+		_, _ = fmt.Fprintf(builder, "  destination: %s\n", dbDiff.DestinationPath)
+		writeSnapshot(builder, "source", dbDiff.SourceSnapshot)
+		writeSnapshot(builder, "destination", dbDiff.DestinationSnapshot)
+		writeCounters(builder, "  ", dbDiff.DiffCounters)
+		writeKeys(builder, "only in source", dbDiff.OnlyInSourceKeys)
+		writeKeys(builder, "only in destination", dbDiff.OnlyInDestinationKeys)
+		writeKeys(builder, "different values", dbDiff.DifferentKeys)
+	}
+
+	_, _ = fmt.Fprintf(builder, "Compared DBs: %d\n", len(report.DBs))
+	writeCounters(builder, "Total ", report.Total)
+	_, _ = fmt.Fprintf(builder, "Source-only DBs: %s\n", strings.Join(report.SourceOnlyDBs, ", "))
+	_, _ = fmt.Fprintf(builder, "Destination-only DBs: %s\n", strings.Join(report.DestinationOnlyDBs, ", "))
+	_, _ = fmt.Fprintf(builder, "Excluded DBs: %s\n", strings.Join(report.ExcludedDBs, ", "))
+	if len(report.Verification) > 0 {
+		_, _ = fmt.Fprintf(builder, "Verification: %s\n", report.Verification)
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+
+	return err
+}
+
+func writeCounters(builder *strings.Builder, prefix string, counters DiffCounters) {
+	_, _ = fmt.Fprintf(builder, "%skeys only in source: %d, only in destination: %d, with different values: %d\n",
+		prefix, counters.OnlyInSource, counters.OnlyInDestination, counters.Different)
+}
+
+func writeSnapshot(builder *strings.Builder, title string, snapshot *common.SnapshotInfo) {
+	if snapshot == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(builder, "  %s snapshot: %s\n", title, snapshot.String())
+}
+
+func writeKeys(builder *strings.Builder, title string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(builder, "  %s:\n", title)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(builder, "    %s\n", key)
+	}
+}
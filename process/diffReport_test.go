@@ -0,0 +1,100 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"iulianpascalau/level-db-copy-go/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createDiffReport() *DiffReport {
+	return &DiffReport{
+		DBs: []*DBDiff{
+			{
+				Name:             "A",
+				SourcePath:       "src/A",
+				DestinationPath:  "dest/A",
+				ExtraSourcePaths: []string{"src2/A", "src3/A"},
+				DiffCounters:     DiffCounters{OnlyInSource: 2, Different: 1},
+				OnlyInSourceKeys: []string{"aa01", "aa02"},
+				DifferentKeys:    []string{"aa03"},
+			},
+			{
+				Name:            "B",
+				SourcePath:      "src/B",
+				DestinationPath: "dest/B",
+				SourceSnapshot: &common.SnapshotInfo{
+					Sequence: 37,
+					Time:     time.Date(2023, 5, 10, 12, 30, 0, 0, time.UTC),
+				},
+			},
+		},
+		SourceOnlyDBs:      []string{"C"},
+		DestinationOnlyDBs: []string{"D", "E"},
+		ExcludedDBs:        make([]string, 0),
+		Total:              DiffCounters{OnlyInSource: 2, Different: 1},
+	}
+}
+
+func TestWriteDiffReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown format should error", func(t *testing.T) {
+		t.Parallel()
+
+		buff := &bytes.Buffer{}
+		err := WriteDiffReport(buff, createDiffReport(), "xml")
+		assert.ErrorIs(t, err, errUnknownOutputFormat)
+		assert.Empty(t, buff.String())
+	})
+	t.Run("text format should work", func(t *testing.T) {
+		t.Parallel()
+
+		buff := &bytes.Buffer{}
+		err := WriteDiffReport(buff, createDiffReport(), TextOutputFormat)
+		assert.Nil(t, err)
+
+		expectedText := `DB A: different
+  source:      src/A
+  extra sources: src2/A, src3/A
+  destination: dest/A
+  keys only in source: 2, only in destination: 0, with different values: 1
+  only in source:
+    aa01
+    aa02
+  different values:
+    aa03
+DB B: identical
+  source:      src/B
+  destination: dest/B
+  source snapshot: sequence 37 at 2023-05-10T12:30:00Z
+  keys only in source: 0, only in destination: 0, with different values: 0
+Compared DBs: 2
+Total keys only in source: 2, only in destination: 0, with different values: 1
+Source-only DBs: C
+Destination-only DBs: D, E
+Excluded DBs: 
+`
+		assert.Equal(t, expectedText, buff.String())
+	})
+	t.Run("JSON format should work", func(t *testing.T) {
+		t.Parallel()
+
+		buff := &bytes.Buffer{}
+		err := WriteDiffReport(buff, createDiffReport(), JSONOutputFormat)
+		assert.Nil(t, err)
+
+		report := &DiffReport{}
+		err = json.Unmarshal(buff.Bytes(), report)
+		assert.Nil(t, err)
+		assert.Equal(t, createDiffReport(), report)
+		assert.Contains(t, buff.String(), `"onlyInSourceKeys": [`)
+		assert.NotContains(t, buff.String(), `"onlyInDestinationKeys"`)
+		assert.Contains(t, buff.String(), `"sourceSnapshot": {`)
+		assert.NotContains(t, buff.String(), `"destinationSnapshot"`)
+	})
+}
@@ -1,26 +1,87 @@
 package process
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
 )
 
+// ArgsDirectoriesHandler is the DTO used to create a new instance of type directories handler
+type ArgsDirectoriesHandler struct {
+	SourceArchive    SourceArchive
+	SourceParentDirs []string
+	DestParentDirs   []string
+	Recursive        bool
+	MappingRules     []string
+	Includes         []string
+	Excludes         []string
+}
+
 type directoriesHandler struct {
-	sourceDirs []string
-	destDirs   []string
+	sourceParentDirs []string
+	destParentDirs   []string
+	sourceDirs       map[string][]string
+	destDirs         map[string][]string
+	mapper           *nameMapper
+	filter           *nameFilter
 }
 
-// NewDirectoriesHandler creates a new instance of type directoriesHandler
-func NewDirectoriesHandler(sourceParentDir string, destParentDir string) (*directoriesHandler, error) {
-	instance := &directoriesHandler{}
+// NewDirectoriesHandler creates a new instance of type directoriesHandler. In recursive mode, the level DB
+// directories are searched at any depth under the parent directories, otherwise only the direct children are used,
+// the source children that are not level DBs being skipped.
+// The mapping rules (source=destination, with * wildcards) pair the source and destination DBs with different names
+// and the include & exclude glob patterns select the DBs to be processed. The source parent directories are
+// provided in priority order. The source archive, if provided, comes first, its sections being seen as the DBs found
+// under the archive path
+func NewDirectoriesHandler(args ArgsDirectoriesHandler) (*directoriesHandler, error) {
+	hasArchive := !check.IfNil(args.SourceArchive)
+	if len(args.SourceParentDirs) == 0 && !hasArchive {
+		return nil, errNoSourceParentDirectory
+	}
+
+	mapper, err := newNameMapper(args.MappingRules)
+	if err != nil {
+		return nil, err
+	}
 
-	var err error
-	instance.sourceDirs, err = getInnerDirectories(sourceParentDir)
+	filter, err := newNameFilter(args.Includes, args.Excludes)
 	if err != nil {
 		return nil, err
 	}
 
-	instance.destDirs, err = getInnerDirectories(destParentDir)
+	instance := &directoriesHandler{
+		sourceParentDirs: args.SourceParentDirs,
+		destParentDirs:   args.DestParentDirs,
+		sourceDirs:       make(map[string][]string, len(args.SourceParentDirs)),
+		destDirs:         make(map[string][]string, len(args.DestParentDirs)),
+		mapper:           mapper,
+		filter:           filter,
+	}
+
+	getSourceDirectories := getInnerLevelDBDirectories
+	getDestDirectories := getInnerDirectories
+	if args.Recursive {
+		getSourceDirectories = getLevelDBDirectories
+		getDestDirectories = getLevelDBDirectories
+	}
+
+	if hasArchive {
+		archivePath := args.SourceArchive.Path()
+		instance.sourceParentDirs = append([]string{archivePath}, args.SourceParentDirs...)
+		instance.sourceDirs[archivePath] = args.SourceArchive.SectionPaths()
+	}
+
+	err = readParentDirectories(args.SourceParentDirs, instance.sourceDirs, getSourceDirectories)
+	if err != nil {
+		return nil, err
+	}
+
+	err = readParentDirectories(args.DestParentDirs, instance.destDirs, getDestDirectories)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +89,23 @@ func NewDirectoriesHandler(sourceParentDir string, destParentDir string) (*direc
 	return instance, nil
 }
 
+func readParentDirectories(parentDirs []string, dirs map[string][]string, getDirectories func(parentDir string) ([]string, error)) error {
+	for _, parentDir := range parentDirs {
+		_, isDuplicated := dirs[parentDir]
+		if isDuplicated {
+			return fmt.Errorf("%w: %s", errDuplicatedParentDirectory, parentDir)
+		}
+
+		var err error
+		dirs[parentDir], err = getDirectories(parentDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func getInnerDirectories(parentDir string) ([]string, error) {
 	dirInfo, err := os.ReadDir(parentDir)
 	if err != nil {
@@ -46,14 +124,93 @@ func getInnerDirectories(parentDir string) ([]string, error) {
 	return result, nil
 }
 
-// SourceDirectories returns the source directories
-func (handler *directoriesHandler) SourceDirectories() []string {
-	return handler.sourceDirs
+// getInnerLevelDBDirectories returns the direct children that are level DBs. The other children are logged and
+// skipped during the selection, instead of failing the copy when their turn comes, after other DBs were written
+func getInnerLevelDBDirectories(parentDir string) ([]string, error) {
+	dirs, err := getInnerDirectories(parentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(dirs))
+	skipped := make([]string, 0)
+	for _, dirPath := range dirs {
+		if isLevelDBDirectory(dirPath) {
+			result = append(result, dirPath)
+		} else {
+			skipped = append(skipped, dirPath)
+		}
+	}
+	if len(skipped) > 0 {
+		log.Warn("skipped the source sub-directories that are not level DBs", "parent dir", parentDir,
+			"sub-directories", strings.Join(skipped, ", "))
+	}
+
+	return result, nil
+}
+
+func getLevelDBDirectories(parentDir string) ([]string, error) {
+	result := make([]string, 0, 1024)
+	err := filepath.WalkDir(parentDir, func(dirPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || dirPath == parentDir {
+			return nil
+		}
+		if !isLevelDBDirectory(dirPath) {
+			return nil
+		}
+
+		result = append(result, filepath.ToSlash(dirPath))
+
+		// a level DB directory does not contain other DBs
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func isLevelDBDirectory(dirPath string) bool {
+	fileInfo, err := os.Stat(filepath.Join(dirPath, currentFileName))
+	if err != nil {
+		return false
+	}
+
+	return !fileInfo.IsDir()
+}
+
+// SourceParentDirectories returns the source parent directories, in priority order
+func (handler *directoriesHandler) SourceParentDirectories() []string {
+	return handler.sourceParentDirs
+}
+
+// DestinationParentDirectories returns the destination parent directories
+func (handler *directoriesHandler) DestinationParentDirectories() []string {
+	return handler.destParentDirs
+}
+
+// SourceDirectories returns the source directories found under the provided source parent directory
+func (handler *directoriesHandler) SourceDirectories(parentDir string) []string {
+	return handler.sourceDirs[parentDir]
 }
 
 // DestinationDirectories returns the destination directories
-func (handler *directoriesHandler) DestinationDirectories() []string {
-	return handler.destDirs
+func (handler *directoriesHandler) DestinationDirectories(parentDir string) []string {
+	return handler.destDirs[parentDir]
+}
+
+// DestinationName returns the name of the destination DB paired with the provided source DB name
+func (handler *directoriesHandler) DestinationName(sourceName string) string {
+	return handler.mapper.destinationName(sourceName)
+}
+
+// IsSelected returns true if the DB with the provided name passes the include & exclude filters
+func (handler *directoriesHandler) IsSelected(name string) bool {
+	return handler.filter.isSelected(name)
 }
 
 // IsInterfaceNil returns true if there is no value under the interface
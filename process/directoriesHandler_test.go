@@ -1,9 +1,15 @@
 package process
 
 import (
+	"os"
+	"path"
+	"path/filepath"
 	"testing"
 
+	"iulianpascalau/level-db-copy-go/testcommon"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewDirectoriesHandler(t *testing.T) {
@@ -12,7 +18,10 @@ func TestNewDirectoriesHandler(t *testing.T) {
 	t.Run("can not read source parent directory should error", func(t *testing.T) {
 		t.Parallel()
 
-		handler, err := NewDirectoriesHandler("/no-root-dir", "./testdata/dir2")
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"/no-root-dir"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+		})
 		assert.Nil(t, handler)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "open /no-root-dir: no such file or directory")
@@ -20,7 +29,10 @@ func TestNewDirectoriesHandler(t *testing.T) {
 	t.Run("can not read destination parent directory should error", func(t *testing.T) {
 		t.Parallel()
 
-		handler, err := NewDirectoriesHandler("./testdata/dir1", "/no-root-dir")
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"/no-root-dir"},
+		})
 		assert.Nil(t, handler)
 		assert.NotNil(t, err)
 		assert.Contains(t, err.Error(), "open /no-root-dir: no such file or directory")
@@ -28,17 +40,20 @@ func TestNewDirectoriesHandler(t *testing.T) {
 	t.Run("should work", func(t *testing.T) {
 		t.Parallel()
 
-		handler, err := NewDirectoriesHandler("./testdata/dir1", "./testdata/dir2")
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+		})
 		assert.NotNil(t, handler)
 		assert.Nil(t, err)
 
-		sourceDirs := handler.SourceDirectories()
+		sourceDirs := handler.SourceDirectories("./testdata/dir1")
 		expectedSourceDirs := []string{
 			"testdata/dir1/aaaa",
 			"testdata/dir1/bbbb",
 		}
 
-		destinationDirs := handler.DestinationDirectories()
+		destinationDirs := handler.DestinationDirectories("./testdata/dir2")
 		expectedDestinationDirs := []string{
 			"testdata/dir2/aaaa",
 			"testdata/dir2/cccc",
@@ -46,6 +61,202 @@ func TestNewDirectoriesHandler(t *testing.T) {
 
 		assert.Equal(t, expectedSourceDirs, sourceDirs)
 		assert.Equal(t, expectedDestinationDirs, destinationDirs)
+		assert.Equal(t, []string{"./testdata/dir1"}, handler.SourceParentDirectories())
+		assert.Equal(t, []string{"./testdata/dir2"}, handler.DestinationParentDirectories())
+	})
+	t.Run("no source parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			DestParentDirs: []string{"./testdata/dir2"},
+		})
+		assert.Nil(t, handler)
+		assert.Equal(t, errNoSourceParentDirectory, err)
+	})
+	t.Run("no destination parent directory should only read the source parent directories", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+		})
+		assert.Nil(t, err)
+		assert.Empty(t, handler.DestinationParentDirectories())
+		assert.Equal(t, []string{"testdata/dir1/aaaa", "testdata/dir1/bbbb"}, handler.SourceDirectories("./testdata/dir1"))
+	})
+	t.Run("duplicated destination parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2", "./testdata/dir2"},
+		})
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errDuplicatedParentDirectory)
+	})
+	t.Run("duplicated source parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1", "./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+		})
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errDuplicatedParentDirectory)
+	})
+	t.Run("should read all the source parent directories", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir2", "./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir1"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"./testdata/dir2", "./testdata/dir1"}, handler.SourceParentDirectories())
+		assert.Equal(t, []string{"testdata/dir2/aaaa", "testdata/dir2/cccc"}, handler.SourceDirectories("./testdata/dir2"))
+		assert.Equal(t, []string{"testdata/dir1/aaaa", "testdata/dir1/bbbb"}, handler.SourceDirectories("./testdata/dir1"))
+		assert.Empty(t, handler.SourceDirectories("./testdata/dir3"))
+	})
+	t.Run("should read all the destination parent directories", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2", "./testdata/dir1"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"./testdata/dir2", "./testdata/dir1"}, handler.DestinationParentDirectories())
+		assert.Equal(t, []string{"testdata/dir2/aaaa", "testdata/dir2/cccc"}, handler.DestinationDirectories("./testdata/dir2"))
+		assert.Equal(t, []string{"testdata/dir1/aaaa", "testdata/dir1/bbbb"}, handler.DestinationDirectories("./testdata/dir1"))
+	})
+	t.Run("should read the source archive first", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceArchive: &testcommon.SourceArchiveStub{
+				PathCalled: func() string {
+					return "export.ldbarch"
+				},
+				SectionPathsCalled: func() []string {
+					return []string{"export.ldbarch/aaaa", "export.ldbarch/cccc"}
+				},
+			},
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"export.ldbarch", "./testdata/dir1"}, handler.SourceParentDirectories())
+		assert.Equal(t, []string{"export.ldbarch/aaaa", "export.ldbarch/cccc"}, handler.SourceDirectories("export.ldbarch"))
+		assert.Equal(t, []string{"testdata/dir1/aaaa", "testdata/dir1/bbbb"}, handler.SourceDirectories("./testdata/dir1"))
+	})
+	t.Run("source archive should be enough as source", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceArchive:  &testcommon.SourceArchiveStub{},
+			DestParentDirs: []string{"./testdata/dir2"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{""}, handler.SourceParentDirectories())
+	})
+	t.Run("recursive should find the level DB directories at any depth", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		require.Nil(t, os.Mkdir(filepath.Join(destParentDir, "notADB"), rwxOwner))
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir3"},
+			DestParentDirs:   []string{destParentDir},
+			Recursive:        true,
+		})
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+
+		expectedSourceDirs := []string{
+			"testdata/dir3/chain/Epoch_1/Shard_0/BlockHeaders",
+			"testdata/dir3/chain/Epoch_1/Shard_0/MiniBlocks",
+			"testdata/dir3/chain/Static/Shard_0/MiniBlocks",
+		}
+		assert.Equal(t, expectedSourceDirs, handler.SourceDirectories("./testdata/dir3"))
+		assert.Empty(t, handler.DestinationDirectories(destParentDir))
+	})
+	t.Run("should skip the source directories that are not level DBs", func(t *testing.T) {
+		t.Parallel()
+
+		parentDir := t.TempDir()
+		for _, name := range []string{"db", "notADB"} {
+			require.Nil(t, os.Mkdir(filepath.Join(parentDir, name), rwxOwner))
+		}
+		require.Nil(t, os.WriteFile(filepath.Join(parentDir, "db", currentFileName), []byte("MANIFEST-000000\n"), 0600))
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{parentDir},
+			DestParentDirs:   []string{parentDir},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{path.Join(parentDir, "db")}, handler.SourceDirectories(parentDir))
+		assert.Equal(t, []string{path.Join(parentDir, "db"), path.Join(parentDir, "notADB")},
+			handler.DestinationDirectories(parentDir))
+	})
+	t.Run("recursive with missing parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir3"},
+			DestParentDirs:   []string{"/no-root-dir"},
+			Recursive:        true,
+		})
+		assert.Nil(t, handler)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "no-root-dir: no such file or directory")
+	})
+	t.Run("invalid mapping rule should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+			MappingRules:     []string{"bbbb"},
+		})
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidMappingRule)
+	})
+	t.Run("should map the destination names", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+			MappingRules:     []string{"bbbb=cccc"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "cccc", handler.DestinationName("bbbb"))
+		assert.Equal(t, "aaaa", handler.DestinationName("aaaa"))
+	})
+	t.Run("invalid filter pattern should error", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+			Excludes:         []string{"[aaaa"},
+		})
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidFilterPattern)
+	})
+	t.Run("should apply the filters", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDirectoriesHandler(ArgsDirectoriesHandler{
+			SourceParentDirs: []string{"./testdata/dir1"},
+			DestParentDirs:   []string{"./testdata/dir2"},
+			Includes:         []string{"a*", "b*"},
+			Excludes:         []string{"bbbb"},
+		})
+		assert.Nil(t, err)
+		assert.True(t, handler.IsSelected("aaaa"))
+		assert.False(t, handler.IsSelected("bbbb"))
+		assert.False(t, handler.IsSelected("cccc"))
 	})
 }
 
@@ -0,0 +1,279 @@
+package process
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type paths struct {
+	src  string
+	dest string
+	// destParentDir is the destination parent directory holding the destination DB
+	destParentDir string
+	// extraSrcs holds the same DB from the lower priority source parent directories, in priority order
+	extraSrcs []string
+	// create is set when the destination DB does not exist yet and it will be created from the source DB
+	create bool
+	// extraDests holds the same DB from the other destination parent directories, filled in the same pass
+	extraDests []destPath
+}
+
+type destPath struct {
+	path      string
+	parentDir string
+	create    bool
+}
+
+func (pathInfo paths) allSrcs() []string {
+	return append([]string{pathInfo.src}, pathInfo.extraSrcs...)
+}
+
+func (pathInfo paths) allDests() []destPath {
+	primary := destPath{
+		path:      pathInfo.dest,
+		parentDir: pathInfo.destParentDir,
+		create:    pathInfo.create,
+	}
+
+	return append([]destPath{primary}, pathInfo.extraDests...)
+}
+
+type dirsSelection struct {
+	dirs     map[string]paths
+	names    []string
+	common   []string
+	srcOnly  []string
+	destOnly []string
+	excluded []string
+}
+
+// logSelection logs the paired, source-only, destination-only and excluded DBs
+func logSelection(selection *dirsSelection, createMissing bool) {
+	log.Info("Common directories between the source and destination parent paths", "sub-directories", strings.Join(selection.common, ", "),
+		"excluded", strings.Join(selection.excluded, ", "))
+	if createMissing {
+		log.Info("Source-only directories, the destination DBs will be created", "sub-directories", strings.Join(selection.srcOnly, ", "))
+	} else {
+		log.Info("Source-only directories, skipped", "sub-directories", strings.Join(selection.srcOnly, ", "))
+	}
+	log.Info("Destination-only directories, skipped", "sub-directories", strings.Join(selection.destOnly, ", "))
+}
+
+type dirsSelector struct {
+	directoriesHandler DirectoriesHandler
+	createMissing      bool
+	destParentDirs     []string
+	destDirs           map[string]map[string]string
+	mappedDestNames    map[string]string
+	seenNames          map[string]struct{}
+	selection          *dirsSelection
+}
+
+// selectDirectories pairs the source and destination DBs by their relative path, as mapped by the directories
+// handler, keeping only the DBs passing the filters. The source-only DBs are selected as well when the missing
+// destination DBs should be created. A DB found under several source parent directories is read from all of them,
+// the first source parent directory holding it being the main source. A DB is written in all the destination parent
+// directories holding it (or where it will be created)
+func selectDirectories(directoriesHandler DirectoriesHandler, createMissing bool) (*dirsSelection, error) {
+	selector := &dirsSelector{
+		directoriesHandler: directoriesHandler,
+		createMissing:      createMissing,
+		destParentDirs:     directoriesHandler.DestinationParentDirectories(),
+		destDirs:           make(map[string]map[string]string),
+		mappedDestNames:    make(map[string]string),
+		seenNames:          make(map[string]struct{}),
+		selection: &dirsSelection{
+			dirs:     make(map[string]paths),
+			names:    make([]string, 0),
+			common:   make([]string, 0),
+			srcOnly:  make([]string, 0),
+			destOnly: make([]string, 0),
+			excluded: make([]string, 0),
+		},
+	}
+	for _, destParentDir := range selector.destParentDirs {
+		selector.destDirs[destParentDir] = convertDirStrings(destParentDir, directoriesHandler.DestinationDirectories(destParentDir))
+	}
+
+	for _, srcParentDir := range directoriesHandler.SourceParentDirectories() {
+		srcDirs := convertDirStrings(srcParentDir, directoriesHandler.SourceDirectories(srcParentDir))
+		err := selector.addSourceDirectories(srcDirs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	selection := selector.selection
+	for _, destParentDir := range selector.destParentDirs {
+		for name := range selector.destDirs[destParentDir] {
+			_, found := selector.mappedDestNames[name]
+			if !found && directoriesHandler.IsSelected(name) {
+				selection.destOnly = append(selection.destOnly, selector.qualifiedName(name, []string{destParentDir}))
+			}
+		}
+	}
+
+	sort.Strings(selection.names)
+	sort.Strings(selection.common)
+	sort.Strings(selection.srcOnly)
+	sort.Strings(selection.destOnly)
+	sort.Strings(selection.excluded)
+
+	return selection, nil
+}
+
+// selectSourceDirectories selects the source DBs passing the filters, without looking at the destination parent
+// directories. A DB found under several source parent directories is read from all of them, in priority order
+func selectSourceDirectories(directoriesHandler DirectoriesHandler) *dirsSelection {
+	selection := &dirsSelection{
+		dirs:     make(map[string]paths),
+		names:    make([]string, 0),
+		excluded: make([]string, 0),
+	}
+	seenNames := make(map[string]struct{})
+	for _, srcParentDir := range directoriesHandler.SourceParentDirectories() {
+		srcDirs := convertDirStrings(srcParentDir, directoriesHandler.SourceDirectories(srcParentDir))
+		for name, srcFullPath := range srcDirs {
+			_, isSeen := seenNames[name]
+			if isSeen {
+				pathInfo, isSelected := selection.dirs[name]
+				if isSelected {
+					pathInfo.extraSrcs = append(pathInfo.extraSrcs, srcFullPath)
+					selection.dirs[name] = pathInfo
+				}
+				continue
+			}
+			seenNames[name] = struct{}{}
+
+			if !directoriesHandler.IsSelected(name) {
+				selection.excluded = append(selection.excluded, name)
+				continue
+			}
+
+			selection.names = append(selection.names, name)
+			selection.dirs[name] = paths{
+				src: srcFullPath,
+			}
+		}
+	}
+
+	sort.Strings(selection.names)
+	sort.Strings(selection.excluded)
+
+	return selection
+}
+
+func (selector *dirsSelector) addSourceDirectories(srcDirs map[string]string) error {
+	selection := selector.selection
+	for name, srcFullPath := range srcDirs {
+		_, isSeen := selector.seenNames[name]
+		if isSeen {
+			pathInfo, isSelected := selection.dirs[name]
+			if isSelected {
+				pathInfo.extraSrcs = append(pathInfo.extraSrcs, srcFullPath)
+				selection.dirs[name] = pathInfo
+			}
+			continue
+		}
+		selector.seenNames[name] = struct{}{}
+
+		if !selector.directoriesHandler.IsSelected(name) {
+			selection.excluded = append(selection.excluded, name)
+			continue
+		}
+
+		destName := selector.directoriesHandler.DestinationName(name)
+		dests, missingIn := selector.findDestinations(destName)
+		if len(missingIn) > 0 {
+			selection.srcOnly = append(selection.srcOnly, selector.qualifiedName(displayName(name, destName), missingIn))
+		}
+		if len(dests) == 0 {
+			continue
+		}
+
+		otherName, isDuplicated := selector.mappedDestNames[destName]
+		if isDuplicated {
+			return fmt.Errorf("%w: %s and %s are mapped to %s", errDuplicatedDestination, otherName, name, destName)
+		}
+		selector.mappedDestNames[destName] = name
+
+		selection.names = append(selection.names, name)
+		if len(missingIn) < len(selector.destParentDirs) {
+			selection.common = append(selection.common, displayName(name, destName))
+		}
+
+		pathInfo := paths{
+			src:           srcFullPath,
+			dest:          dests[0].path,
+			destParentDir: dests[0].parentDir,
+			create:        dests[0].create,
+		}
+		if len(dests) > 1 {
+			pathInfo.extraDests = dests[1:]
+		}
+		selection.dirs[name] = pathInfo
+	}
+
+	return nil
+}
+
+// findDestinations returns the destination DBs, in the destination parent directories order, and the destination
+// parent directories missing the DB. The missing DBs are returned as DBs to be created, if set so
+func (selector *dirsSelector) findDestinations(destName string) ([]destPath, []string) {
+	dests := make([]destPath, 0, len(selector.destParentDirs))
+	missingIn := make([]string, 0)
+	for _, destParentDir := range selector.destParentDirs {
+		destFullPath, found := selector.destDirs[destParentDir][destName]
+		if found {
+			dests = append(dests, destPath{path: destFullPath, parentDir: destParentDir})
+			continue
+		}
+
+		missingIn = append(missingIn, destParentDir)
+		if selector.createMissing {
+			dests = append(dests, destPath{path: path.Join(destParentDir, destName), parentDir: destParentDir, create: true})
+		}
+	}
+
+	return dests, missingIn
+}
+
+// qualifiedName adds the destination parent directories to the DB name, when there are several of them
+func (selector *dirsSelector) qualifiedName(name string, destParentDirs []string) string {
+	if len(selector.destParentDirs) < 2 {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, strings.Join(destParentDirs, ", "))
+}
+
+func displayName(srcName string, destName string) string {
+	if srcName == destName {
+		return srcName
+	}
+
+	return srcName + " -> " + destName
+}
+
+// convertDirStrings maps the directories by their path relative to the parent directory
+func convertDirStrings(parentDir string, dirStrings []string) map[string]string {
+	mapDirs := make(map[string]string, len(dirStrings))
+	for _, dir := range dirStrings {
+		mapDirs[relativeDirName(parentDir, dir)] = dir
+	}
+
+	return mapDirs
+}
+
+func relativeDirName(parentDir string, dir string) string {
+	relativePath, err := filepath.Rel(parentDir, dir)
+	if err != nil {
+		_, lastDirElement := path.Split(dir)
+		return lastDirElement
+	}
+
+	return filepath.ToSlash(relativePath)
+}
@@ -0,0 +1,63 @@
+package process
+
+import (
+	"iulianpascalau/level-db-copy-go/common"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// disabledDBWrapper is an always empty DB that does not touch the disk, used when simulating a DB creation
+type disabledDBWrapper struct{}
+
+func newDisabledDBWrapper() *disabledDBWrapper {
+	return &disabledDBWrapper{}
+}
+
+// Open does nothing
+func (wrapper *disabledDBWrapper) Open(_ string) error {
+	return nil
+}
+
+// RangeKeys does nothing as there are no keys
+func (wrapper *disabledDBWrapper) RangeKeys(_ func(key []byte, val []byte) bool) {
+}
+
+// NewIterator returns an empty iterator
+func (wrapper *disabledDBWrapper) NewIterator(_ []byte, _ []byte) (common.DBIterator, error) {
+	return newEmptyDBIterator(nil), nil
+}
+
+// SnapshotInfo returns nil
+func (wrapper *disabledDBWrapper) SnapshotInfo() *common.SnapshotInfo {
+	return nil
+}
+
+// Get returns the not found error
+func (wrapper *disabledDBWrapper) Get(_ []byte) ([]byte, error) {
+	return nil, leveldb.ErrNotFound
+}
+
+// Put returns the read-only error
+func (wrapper *disabledDBWrapper) Put(_ []byte, _ []byte) error {
+	return errReadOnlyDB
+}
+
+// WriteBatch returns the read-only error
+func (wrapper *disabledDBWrapper) WriteBatch(_ [][]byte, _ [][]byte, _ [][]byte, _ bool) error {
+	return errReadOnlyDB
+}
+
+// Remove returns the read-only error
+func (wrapper *disabledDBWrapper) Remove(_ []byte) error {
+	return errReadOnlyDB
+}
+
+// Close does nothing
+func (wrapper *disabledDBWrapper) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (wrapper *disabledDBWrapper) IsInterfaceNil() bool {
+	return wrapper == nil
+}
@@ -0,0 +1,177 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+)
+
+// ArgsDumpHandler is the DTO used to create a new instance of type dump handler
+type ArgsDumpHandler struct {
+	DirectoriesHandler DirectoriesHandler
+	DBWrapperFactory   DBWrapperFactory
+	DBPath             string
+	KeyRange           KeyRange
+	RecordFormat       string
+	KeyEncoding        string
+	ValueEncoding      string
+	Limit              int
+	KeysOnly           bool
+}
+
+type dumpHandler struct {
+	directoriesHandler DirectoriesHandler
+	dbWrapperFactory   DBWrapperFactory
+	dbPath             string
+	keyRange           KeyRange
+	recordFormat       string
+	keyEncoding        string
+	valueEncoding      string
+	limit              int
+	keysOnly           bool
+}
+
+// NewDumpHandler creates a new instance of type dump handler. If the DB path is provided, only that DB is dumped and
+// the directories handler is not used
+func NewDumpHandler(args ArgsDumpHandler) (*dumpHandler, error) {
+	if len(args.DBPath) == 0 && check.IfNil(args.DirectoriesHandler) {
+		return nil, errNilDirectoriesHandler
+	}
+	if check.IfNil(args.DBWrapperFactory) {
+		return nil, errNilDBWrapperFactory
+	}
+	err := CheckRecordFormat(args.RecordFormat)
+	if err != nil {
+		return nil, err
+	}
+	err = CheckEncoding(args.KeyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the keys", err)
+	}
+	err = CheckEncoding(args.ValueEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the values", err)
+	}
+	if args.Limit < 0 {
+		return nil, fmt.Errorf("%w %d, should be at least 0", errInvalidLimit, args.Limit)
+	}
+
+	return &dumpHandler{
+		directoriesHandler: args.DirectoriesHandler,
+		dbWrapperFactory:   args.DBWrapperFactory,
+		dbPath:             args.DBPath,
+		keyRange:           args.KeyRange,
+		recordFormat:       args.RecordFormat,
+		keyEncoding:        args.KeyEncoding,
+		valueEncoding:      args.ValueEncoding,
+		limit:              args.Limit,
+		keysOnly:           args.KeysOnly,
+	}, nil
+}
+
+// Process writes the records of the selected source DBs, in ascending key order, each record holding the DB name.
+// When the DB is found in several source parent directories, the sources are merged in priority order. The limit,
+// if not 0, applies to each DB. The source DBs are opened in read-only mode
+func (handler *dumpHandler) Process(writer io.Writer) error {
+	selection := handler.selectDirectories()
+
+	bufferedWriter := bufio.NewWriter(writer)
+	records, err := newRecordWriter(bufferedWriter, handler.recordFormat, handler.keysOnly)
+	if err != nil {
+		return err
+	}
+
+	numRecords := 0
+	for _, name := range selection.names {
+		numDBRecords, errDump := handler.dumpDB(records, name, selection.dirs[name])
+		if errDump != nil {
+			return errDump
+		}
+
+		log.Info("dumped DB", "name", name, "records", numDBRecords)
+		numRecords += numDBRecords
+	}
+
+	err = records.flush()
+	if err != nil {
+		return err
+	}
+	err = bufferedWriter.Flush()
+	if err != nil {
+		return err
+	}
+
+	log.Info("summary", "dumped DBs", len(selection.names), "records", numRecords, "format", handler.recordFormat)
+
+	return nil
+}
+
+func (handler *dumpHandler) selectDirectories() *dirsSelection {
+	if len(handler.dbPath) > 0 {
+		name := filepath.Base(handler.dbPath)
+		return &dirsSelection{
+			dirs:  map[string]paths{name: {src: handler.dbPath}},
+			names: []string{name},
+		}
+	}
+
+	selection := selectSourceDirectories(handler.directoriesHandler)
+	log.Info("Source directories to dump", "sub-directories", strings.Join(selection.names, ", "),
+		"excluded", strings.Join(selection.excluded, ", "))
+
+	return selection
+}
+
+func (handler *dumpHandler) dumpDB(records recordWriter, name string, pathInfo paths) (int, error) {
+	srcDBWrapper, err := openReadOnly(handler.dbWrapperFactory, pathInfo.src)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = srcDBWrapper.Close()
+	}()
+
+	extraSrcDBWrappers, err := openExtraSources(handler.dbWrapperFactory, name, pathInfo)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = closeAll(extraSrcDBWrappers)
+	}()
+
+	srcDBWrappers := append([]DBWrapper{srcDBWrapper}, extraSrcDBWrappers...)
+	iterator, err := newSourcesIterator(srcDBWrappers, handler.keyRange.Start, handler.keyRange.End, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Release()
+
+	numRecords := 0
+	for iterator.Next() {
+		if handler.limit > 0 && numRecords >= handler.limit {
+			break
+		}
+
+		record, errRecord := newDBRecord(name, iterator.Key(), iterator.Value(), handler.keyEncoding,
+			handler.valueEncoding, handler.keysOnly)
+		if errRecord != nil {
+			return numRecords, errRecord
+		}
+
+		err = records.writeRecord(record)
+		if err != nil {
+			return numRecords, err
+		}
+		numRecords++
+	}
+	err = iterator.Error()
+	if err != nil {
+		return numRecords, fmt.Errorf("%w while dumping %s", err, pathInfo.src)
+	}
+
+	return numRecords, nil
+}
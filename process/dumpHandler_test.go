@@ -0,0 +1,205 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createMockArgsDumpHandler() ArgsDumpHandler {
+	return ArgsDumpHandler{
+		DirectoriesHandler: &testcommon.DirectoriesHandlerStub{},
+		DBWrapperFactory:   &dbWrapperFactoryStub{},
+		RecordFormat:       JSONLRecordFormat,
+		KeyEncoding:        AutoEncoding,
+		ValueEncoding:      AutoEncoding,
+	}
+}
+
+func TestNewDumpHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil directories handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.DirectoriesHandler = nil
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDirectoriesHandler, err)
+	})
+	t.Run("nil directories handler with DB path should work", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.DirectoriesHandler = nil
+		args.DBPath = "db"
+		handler, err := NewDumpHandler(args)
+
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.DBWrapperFactory = nil
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("unknown record format should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.RecordFormat = "xml"
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownRecordFormat)
+	})
+	t.Run("unknown key encoding should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.KeyEncoding = "binary"
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownEncoding)
+		assert.Contains(t, err.Error(), "for the keys")
+	})
+	t.Run("unknown value encoding should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.ValueEncoding = "binary"
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownEncoding)
+		assert.Contains(t, err.Error(), "for the values")
+	})
+	t.Run("negative limit should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.Limit = -1
+		handler, err := NewDumpHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errInvalidLimit)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewDumpHandler(createMockArgsDumpHandler())
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+}
+
+func TestDumpHandler_Process(t *testing.T) {
+	t.Parallel()
+
+	contents := map[string][]string{
+		"src1/A":    {"a1", "v1", "a3", "v3"},
+		"src2/A":    {"a2", "v2", "a3", "x3"},
+		"src1/B":    {"b1", "v1"},
+		"other/DB1": {"k1", "v1", "k2", "v2"},
+	}
+	directoriesHandler := &testcommon.DirectoriesHandlerStub{
+		SourceParentDirectoriesCalled: func() []string {
+			return []string{"src1", "src2"}
+		},
+		SourceDirectoriesCalled: func(parentDir string) []string {
+			if parentDir == "src1" {
+				return []string{"src1/A", "src1/B", "src1/E"}
+			}
+			return []string{"src2/A"}
+		},
+		IsSelectedCalled: func(name string) bool {
+			return name != "E"
+		},
+	}
+
+	t.Run("should dump all the selected DBs", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsDumpHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		args.RecordFormat = CSVRecordFormat
+		args.KeyEncoding = HexEncoding
+		handler, _ := NewDumpHandler(args)
+
+		buff := &bytes.Buffer{}
+		err := handler.Process(buff)
+		assert.Nil(t, err)
+
+		expected := "db,key,keyEncoding,value,valueEncoding\n" +
+			"A,6131,hex,v1,utf8\n" +
+			"A,6132,hex,v2,utf8\n" +
+			"A,6133,hex,v3,utf8\n" +
+			"B,6231,hex,v1,utf8\n"
+		assert.Equal(t, expected, buff.String())
+		assert.ElementsMatch(t, []string{"src1/A", "src2/A", "src1/B"}, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+	})
+	t.Run("should dump the provided DB with limit and keys only", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsDumpHandler()
+		args.DirectoriesHandler = nil
+		args.DBPath = "other/DB1"
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		args.Limit = 1
+		args.KeysOnly = true
+		handler, _ := NewDumpHandler(args)
+
+		buff := &bytes.Buffer{}
+		err := handler.Process(buff)
+		assert.Nil(t, err)
+
+		assert.Equal(t, `{"db":"DB1","key":"k1","keyEncoding":"utf8"}`+"\n", buff.String())
+		assert.Equal(t, []string{"other/DB1"}, rec.srcOpenedDBs)
+		assert.Equal(t, []string{"other/DB1"}, rec.srcClosedDBs)
+	})
+	t.Run("encoding error should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsDumpHandler()
+		args.DBPath = "db"
+		args.DBWrapperFactory = createDBsForDiff(t, map[string][]string{"db": {"k1", string([]byte{0xff})}}, &recorder{})
+		args.ValueEncoding = UTF8Encoding
+		handler, _ := NewDumpHandler(args)
+
+		err := handler.Process(&bytes.Buffer{})
+		assert.ErrorIs(t, err, errInvalidUTF8Data)
+		assert.Contains(t, err.Error(), "key 6b31, DB db")
+	})
+	t.Run("open error should error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		args := createMockArgsDumpHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = wrapFactory(createDBsForDiff(t, contents, &recorder{}), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.OpenCalled = func(path string) error {
+				return expectedErr
+			}
+		})
+		handler, _ := NewDumpHandler(args)
+
+		err := handler.Process(&bytes.Buffer{})
+		assert.Equal(t, expectedErr, err)
+	})
+}
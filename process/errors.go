@@ -3,8 +3,47 @@ package process
 import "errors"
 
 var (
-	errInnerDBIsNotOpened    = errors.New("inner DB is not opened")
-	errInnerDBIsNotClosed    = errors.New("inner DB is not closed")
-	errNilDirectoriesHandler = errors.New("nil directories handler instance")
-	errNilDBWrapper          = errors.New("nil DB wrapper instance")
+	errInnerDBIsNotOpened           = errors.New("inner DB is not opened")
+	errInnerDBIsNotClosed           = errors.New("inner DB is not closed")
+	errNilDirectoriesHandler        = errors.New("nil directories handler instance")
+	errNilDBWrapper                 = errors.New("nil DB wrapper instance")
+	errNilDBWrapperFactory          = errors.New("nil DB wrapper factory instance")
+	errInvalidNumWorkers            = errors.New("invalid number of workers")
+	errInvalidBatchSize             = errors.New("invalid batch size")
+	errKeysValuesLengthMismatch     = errors.New("keys and values length mismatch")
+	errReadOnlyDB                   = errors.New("the DB is opened in read-only mode")
+	errNotALevelDB                  = errors.New("the directory is not an existing level DB")
+	errNilLockChecker               = errors.New("nil lock checker instance")
+	errLockedDBs                    = errors.New("DBs locked by another process")
+	errNilConflictResolver          = errors.New("nil conflict resolver instance")
+	errUnknownConflictPolicy        = errors.New("unknown conflict policy")
+	errConflictingValuesFound       = errors.New("conflicting values found")
+	errNilCheckpointHandler         = errors.New("nil checkpoint handler instance")
+	errInvalidCheckpointFile        = errors.New("invalid checkpoint file")
+	errCheckpointRunMismatch        = errors.New("the checkpoint file was written by a run with different paths or options")
+	errInvalidMappingRule           = errors.New("invalid mapping rule")
+	errDuplicatedDestination        = errors.New("multiple source DBs are mapped to the same destination DB")
+	errInvalidFilterPattern         = errors.New("invalid filter pattern")
+	errInvalidKey                   = errors.New("invalid key")
+	errEmptyKeyRange                = errors.New("empty key range")
+	errInvalidMaxListedKeys         = errors.New("invalid maximum number of listed keys")
+	errUnknownOutputFormat          = errors.New("unknown output format")
+	errVerificationFailed           = errors.New("verification failed")
+	errUnknownCopyStrategy          = errors.New("unknown copy strategy")
+	errIncompatibleOptions          = errors.New("incompatible options")
+	errInvalidDeletionsCap          = errors.New("invalid deletions cap")
+	errDeletionsCapExceeded         = errors.New("deletions cap exceeded")
+	errNoSourceParentDirectory      = errors.New("no source parent directory provided")
+	errNoDestinationParentDirectory = errors.New("no destination parent directory provided")
+	errDuplicatedParentDirectory    = errors.New("duplicated parent directory")
+	errMirrorNotConfirmed           = errors.New("the mirror mode removes data from the destination DBs and must be confirmed")
+	errInvalidArchive               = errors.New("invalid archive")
+	errUnsupportedArchiveVersion    = errors.New("unsupported archive version")
+	errArchiveChecksumMismatch      = errors.New("archive checksum mismatch")
+	errUnknownArchiveSection        = errors.New("unknown archive section")
+	errUnknownEncoding              = errors.New("unknown encoding")
+	errUnknownRecordFormat          = errors.New("unknown record format")
+	errInvalidUTF8Data              = errors.New("the data is not valid UTF-8 text")
+	errInvalidLimit                 = errors.New("invalid limit")
+	errInvalidRecord                = errors.New("invalid record")
 )
@@ -0,0 +1,179 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"iulianpascalau/level-db-copy-go/common"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+)
+
+// ArgsExportHandler is the DTO used to create a new instance of type export handler
+type ArgsExportHandler struct {
+	DirectoriesHandler DirectoriesHandler
+	DBWrapperFactory   DBWrapperFactory
+	KeyRange           KeyRange
+	MissingOnly        bool
+}
+
+type exportHandler struct {
+	directoriesHandler DirectoriesHandler
+	dbWrapperFactory   DBWrapperFactory
+	keyRange           KeyRange
+	missingOnly        bool
+}
+
+type exportResult struct {
+	numKeys  int
+	numBytes int
+}
+
+// NewExportHandler creates a new instance of type export handler
+func NewExportHandler(args ArgsExportHandler) (*exportHandler, error) {
+	if check.IfNil(args.DirectoriesHandler) {
+		return nil, errNilDirectoriesHandler
+	}
+	if check.IfNil(args.DBWrapperFactory) {
+		return nil, errNilDBWrapperFactory
+	}
+	if args.MissingOnly && len(args.DirectoriesHandler.DestinationParentDirectories()) != 1 {
+		return nil, fmt.Errorf("%w: the missing-only export requires a single destination parent directory", errIncompatibleOptions)
+	}
+
+	return &exportHandler{
+		directoriesHandler: args.DirectoriesHandler,
+		dbWrapperFactory:   args.DBWrapperFactory,
+		keyRange:           args.KeyRange,
+		missingOnly:        args.MissingOnly,
+	}, nil
+}
+
+// Process writes the selected source DBs in an archive, one section for each DB, named after the destination DB.
+// In missing-only mode, only the keys missing from the destination tree are written, the DBs missing from the
+// destination tree being fully exported. The source DBs are opened in read-only mode
+func (handler *exportHandler) Process(writer io.Writer) error {
+	selection, err := handler.selectDirectories()
+	if err != nil {
+		return err
+	}
+
+	archive, err := newArchiveWriter(writer)
+	if err != nil {
+		return err
+	}
+
+	total := exportResult{}
+	for index, name := range selection.names {
+		sectionName := handler.directoriesHandler.DestinationName(name)
+		log.Info("now exporting sub-directory", "name", name, "overall progress", fmt.Sprintf("%d/%d", index+1, len(selection.names)))
+
+		result, errExport := handler.exportDB(archive, name, sectionName, selection.dirs[name])
+		if errExport != nil {
+			return errExport
+		}
+
+		log.Info("exported DB", "name", name, "section", sectionName, "keys", result.numKeys, "bytes", result.numBytes)
+		total.numKeys += result.numKeys
+		total.numBytes += result.numBytes
+	}
+
+	err = archive.close()
+	if err != nil {
+		return err
+	}
+
+	log.Info("summary", "exported DBs", len(selection.names), "keys", total.numKeys, "bytes", total.numBytes,
+		"missing only", handler.missingOnly, "archive version", ArchiveVersion)
+
+	return nil
+}
+
+func (handler *exportHandler) selectDirectories() (*dirsSelection, error) {
+	if !handler.missingOnly {
+		selection := selectSourceDirectories(handler.directoriesHandler)
+		log.Info("Source directories to export", "sub-directories", strings.Join(selection.names, ", "),
+			"excluded", strings.Join(selection.excluded, ", "))
+		return selection, nil
+	}
+
+	selection, err := selectDirectories(handler.directoriesHandler, true)
+	if err != nil {
+		return nil, err
+	}
+	logSelection(selection, true)
+
+	return selection, nil
+}
+
+func (handler *exportHandler) exportDB(archive *archiveWriter, name string, sectionName string, pathInfo paths) (exportResult, error) {
+	result := exportResult{}
+	srcDBWrapper, err := openReadOnly(handler.dbWrapperFactory, pathInfo.src)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = srcDBWrapper.Close()
+	}()
+	log.Info("reading the source DB", "name", name, "path", pathInfo.src, "snapshot", srcDBWrapper.SnapshotInfo().String())
+
+	extraSrcDBWrappers, err := openExtraSources(handler.dbWrapperFactory, name, pathInfo)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = closeAll(extraSrcDBWrappers)
+	}()
+
+	srcDBWrappers := append([]DBWrapper{srcDBWrapper}, extraSrcDBWrappers...)
+	srcIterator, err := newSourcesIterator(srcDBWrappers, handler.keyRange.Start, handler.keyRange.End, nil)
+	if err != nil {
+		return result, err
+	}
+	defer srcIterator.Release()
+
+	// in missing-only mode, the keys found in the destination DB are skipped
+	var destIterator common.DBIterator = newEmptyDBIterator(nil)
+	if handler.missingOnly && !pathInfo.create {
+		destDBWrapper, errOpen := openReadOnly(handler.dbWrapperFactory, pathInfo.dest)
+		if errOpen != nil {
+			return result, errOpen
+		}
+		defer func() {
+			_ = destDBWrapper.Close()
+		}()
+
+		destIterator, err = destDBWrapper.NewIterator(handler.keyRange.Start, handler.keyRange.End)
+		if err != nil {
+			return result, err
+		}
+	}
+	defer destIterator.Release()
+
+	err = archive.startSection(sectionName)
+	if err != nil {
+		return result, err
+	}
+
+	var errWrite error
+	err = walkMerged(srcIterator, destIterator, func(entry *mergedEntry) bool {
+		if !entry.inSrc || entry.inDest {
+			return true
+		}
+
+		errWrite = archive.writeRecord(entry.key, entry.srcValue)
+		result.numKeys++
+		result.numBytes += len(entry.key) + len(entry.srcValue)
+
+		return errWrite == nil
+	})
+	if errWrite != nil {
+		return result, errWrite
+	}
+	if err != nil {
+		return result, fmt.Errorf("%w while exporting %s", err, pathInfo.src)
+	}
+
+	return result, archive.endSection()
+}
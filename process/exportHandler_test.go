@@ -0,0 +1,210 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/common"
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createMockArgsExportHandler() ArgsExportHandler {
+	return ArgsExportHandler{
+		DirectoriesHandler: &testcommon.DirectoriesHandlerStub{},
+		DBWrapperFactory:   &dbWrapperFactoryStub{},
+	}
+}
+
+func TestNewExportHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil directories handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsExportHandler()
+		args.DirectoriesHandler = nil
+		handler, err := NewExportHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDirectoriesHandler, err)
+	})
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsExportHandler()
+		args.DBWrapperFactory = nil
+		handler, err := NewExportHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("missing-only mode with multiple destination parent directories should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsExportHandler()
+		args.MissingOnly = true
+		args.DirectoriesHandler = &testcommon.DirectoriesHandlerStub{
+			DestinationParentDirectoriesCalled: func() []string {
+				return []string{"dest1", "dest2"}
+			},
+		}
+		handler, err := NewExportHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewExportHandler(createMockArgsExportHandler())
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+}
+
+func TestExportHandler_Process(t *testing.T) {
+	t.Parallel()
+
+	contents := map[string][]string{
+		"src1/A": {"a1", "v1", "a3", "v3"},
+		"src2/A": {"a2", "v2", "a3", "x3"},
+		"src1/B": {"b1", "v1"},
+		"src1/C": {"c1", "v1"},
+		"dest/A": {"a1", "v1"},
+		"dest/B": {"b1", "v1"},
+	}
+	directoriesHandler := &testcommon.DirectoriesHandlerStub{
+		SourceParentDirectoriesCalled: func() []string {
+			return []string{"src1", "src2"}
+		},
+		SourceDirectoriesCalled: func(parentDir string) []string {
+			if parentDir == "src1" {
+				return []string{"src1/A", "src1/B", "src1/C", "src1/E"}
+			}
+			return []string{"src2/A"}
+		},
+		DestinationParentDirectoriesCalled: func() []string {
+			return []string{"dest"}
+		},
+		DestinationDirectoriesCalled: func(parentDir string) []string {
+			return []string{"dest/A", "dest/B"}
+		},
+		DestinationNameCalled: func(sourceName string) string {
+			if sourceName == "C" {
+				return "D"
+			}
+			return sourceName
+		},
+		IsSelectedCalled: func(name string) bool {
+			return name != "E"
+		},
+	}
+
+	t.Run("should export all the source keys", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsExportHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		handler, _ := NewExportHandler(args)
+
+		buff := &bytes.Buffer{}
+		err := handler.Process(buff)
+		assert.Nil(t, err)
+
+		expectedSections := []*archiveSection{
+			{
+				name:   "A",
+				keys:   [][]byte{[]byte("a1"), []byte("a2"), []byte("a3")},
+				values: [][]byte{[]byte("v1"), []byte("v2"), []byte("v3")},
+			},
+			{
+				name:   "B",
+				keys:   [][]byte{[]byte("b1")},
+				values: [][]byte{[]byte("v1")},
+			},
+			{
+				name:   "D",
+				keys:   [][]byte{[]byte("c1")},
+				values: [][]byte{[]byte("v1")},
+			},
+		}
+		sections, err := readSections(buff.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, expectedSections, sections)
+		assert.ElementsMatch(t, []string{"src1/A", "src2/A", "src1/B", "src1/C"}, rec.srcOpenedDBs)
+		assert.ElementsMatch(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+	})
+	t.Run("missing-only mode should export only the keys missing from the destination", func(t *testing.T) {
+		t.Parallel()
+
+		rec := &recorder{}
+		args := createMockArgsExportHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = createDBsForDiff(t, contents, rec)
+		args.MissingOnly = true
+		handler, _ := NewExportHandler(args)
+
+		buff := &bytes.Buffer{}
+		err := handler.Process(buff)
+		assert.Nil(t, err)
+
+		expectedSections := []*archiveSection{
+			{
+				name:   "A",
+				keys:   [][]byte{[]byte("a2"), []byte("a3")},
+				values: [][]byte{[]byte("v2"), []byte("v3")},
+			},
+			{
+				name: "B",
+			},
+			{
+				name:   "D",
+				keys:   [][]byte{[]byte("c1")},
+				values: [][]byte{[]byte("v1")},
+			},
+		}
+		sections, err := readSections(buff.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, expectedSections, sections)
+		assert.ElementsMatch(t, rec.srcOpenedDBs, rec.srcClosedDBs)
+		assert.Contains(t, rec.srcOpenedDBs, "dest/A")
+		assert.NotContains(t, rec.srcOpenedDBs, "dest/D")
+	})
+	t.Run("open error should error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		args := createMockArgsExportHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = wrapFactory(createDBsForDiff(t, contents, &recorder{}), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.OpenCalled = func(path string) error {
+				return expectedErr
+			}
+		})
+		handler, _ := NewExportHandler(args)
+
+		err := handler.Process(&bytes.Buffer{})
+		assert.Equal(t, expectedErr, err)
+	})
+	t.Run("iteration error should error", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		args := createMockArgsExportHandler()
+		args.DirectoriesHandler = directoriesHandler
+		args.DBWrapperFactory = wrapFactory(createDBsForDiff(t, contents, &recorder{}), func(wrapper *testcommon.DBWrapperStub, role DBRole) {
+			wrapper.NewIteratorCalled = func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return testcommon.NewInMemoryIteratorWithError(expectedErr), nil
+			}
+		})
+		handler, _ := NewExportHandler(args)
+
+		err := handler.Process(&bytes.Buffer{})
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
@@ -1,18 +1,67 @@
 package process
 
+import "iulianpascalau/level-db-copy-go/common"
+
 // DBWrapper defines the operations supported by a database wrapper
 type DBWrapper interface {
 	Open(path string) error
 	RangeKeys(handler func(key []byte, val []byte) bool)
+	NewIterator(startKey []byte, endKey []byte) (common.DBIterator, error)
+	SnapshotInfo() *common.SnapshotInfo
 	Get(key []byte) ([]byte, error)
 	Put(key, val []byte) error
+	WriteBatch(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error
+	Remove(key []byte) error
 	Close() error
 	IsInterfaceNil() bool
 }
 
+// DBWrapperFactory defines the operations supported by a component able to create DB wrappers. A new, not yet opened,
+// DB wrapper is created for each DB path, so the factory can choose the backend based on the path and role
+type DBWrapperFactory interface {
+	Create(path string, role DBRole) (DBWrapper, error)
+	IsInterfaceNil() bool
+}
+
 // DirectoriesHandler defines the operations supported by a directories handler
 type DirectoriesHandler interface {
-	SourceDirectories() []string
-	DestinationDirectories() []string
+	SourceParentDirectories() []string
+	DestinationParentDirectories() []string
+	SourceDirectories(parentDir string) []string
+	DestinationDirectories(parentDir string) []string
+	DestinationName(sourceName string) string
+	IsSelected(name string) bool
+	IsInterfaceNil() bool
+}
+
+// SourceArchive defines the operations supported by an archive used as a copy source. Each archive section is seen
+// as a source DB found under the archive path
+type SourceArchive interface {
+	Path() string
+	SectionPaths() []string
+	IsInterfaceNil() bool
+}
+
+// ConflictResolver defines the operations supported by a component that decides what happens with a key that
+// exists in both the source and the destination DBs but with different values. Implementations should be safe
+// for concurrent use as the DBs can be processed in parallel
+type ConflictResolver interface {
+	ShouldOverwrite(key []byte, srcVal []byte, destVal []byte) (bool, error)
+	IsInterfaceNil() bool
+}
+
+// CheckpointHandler defines the operations supported by a component that records the copy progress
+type CheckpointHandler interface {
+	IsCompleted(name string) bool
+	LastKey(name string) []byte
+	SaveProgress(name string, lastKey []byte) error
+	MarkCompleted(name string) error
+	Clear() error
+	IsInterfaceNil() bool
+}
+
+// LockChecker defines the operations supported by a component able to tell if a DB is held by another process
+type LockChecker interface {
+	IsLocked(path string) (bool, error)
 	IsInterfaceNil() bool
 }
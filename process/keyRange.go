@@ -0,0 +1,94 @@
+package process
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const hexKeyPrefix = "0x"
+
+// KeyRange holds the [Start, End) interval of the keys to be processed. A nil Start means the first key and
+// a nil End means past the last key
+type KeyRange struct {
+	Start []byte
+	End   []byte
+}
+
+// NewKeyRange creates the key range from the start, end and prefix values provided as strings. A value starting
+// with 0x is decoded as hex, otherwise its UTF-8 bytes are used. Empty values are ignored. When a prefix is
+// provided, the range is narrowed to the keys starting with that prefix
+func NewKeyRange(start string, end string, prefix string) (KeyRange, error) {
+	keyRange := KeyRange{}
+
+	var err error
+	keyRange.Start, err = ParseKey(start)
+	if err != nil {
+		return KeyRange{}, fmt.Errorf("%w for the start key", err)
+	}
+	keyRange.End, err = ParseKey(end)
+	if err != nil {
+		return KeyRange{}, fmt.Errorf("%w for the end key", err)
+	}
+	prefixBytes, err := ParseKey(prefix)
+	if err != nil {
+		return KeyRange{}, fmt.Errorf("%w for the prefix", err)
+	}
+
+	if len(prefixBytes) > 0 {
+		prefixRange := util.BytesPrefix(prefixBytes)
+		if bytes.Compare(prefixRange.Start, keyRange.Start) > 0 {
+			keyRange.Start = prefixRange.Start
+		}
+		if keyRange.End == nil || (prefixRange.Limit != nil && bytes.Compare(prefixRange.Limit, keyRange.End) < 0) {
+			keyRange.End = prefixRange.Limit
+		}
+	}
+
+	if keyRange.End != nil && bytes.Compare(keyRange.Start, keyRange.End) >= 0 {
+		return KeyRange{}, fmt.Errorf("%w, start %s, end %s", errEmptyKeyRange, hex.EncodeToString(keyRange.Start),
+			hex.EncodeToString(keyRange.End))
+	}
+
+	return keyRange, nil
+}
+
+// ParseKey converts a key provided as string. A value starting with 0x is decoded as hex, otherwise its
+// UTF-8 bytes are used. An empty value returns nil
+func ParseKey(value string) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if !strings.HasPrefix(value, hexKeyPrefix) {
+		return []byte(value), nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(value, hexKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %s", errInvalidKey, value, err.Error())
+	}
+
+	return key, nil
+}
+
+// IsFullRange returns true if the range is not bounded
+func (keyRange KeyRange) IsFullRange() bool {
+	return keyRange.Start == nil && keyRange.End == nil
+}
+
+// String returns the hex representation of the range
+func (keyRange KeyRange) String() string {
+	if keyRange.IsFullRange() {
+		return "all keys"
+	}
+
+	end := "end"
+	if keyRange.End != nil {
+		end = hex.EncodeToString(keyRange.End)
+	}
+
+	return fmt.Sprintf("[%s, %s)", hex.EncodeToString(keyRange.Start), end)
+}
@@ -0,0 +1,98 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := ParseKey("")
+	assert.Nil(t, err)
+	assert.Nil(t, key)
+
+	key, err = ParseKey("MiniBlock")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("MiniBlock"), key)
+
+	key, err = ParseKey("0x0aff")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x0a, 0xff}, key)
+
+	key, err = ParseKey("0xzz")
+	assert.Nil(t, key)
+	assert.ErrorIs(t, err, errInvalidKey)
+}
+
+func TestNewKeyRange(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid values should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewKeyRange("0xz", "", "")
+		assert.ErrorIs(t, err, errInvalidKey)
+		assert.Contains(t, err.Error(), "start key")
+
+		_, err = NewKeyRange("", "0xz", "")
+		assert.ErrorIs(t, err, errInvalidKey)
+		assert.Contains(t, err.Error(), "end key")
+
+		_, err = NewKeyRange("", "", "0xz")
+		assert.ErrorIs(t, err, errInvalidKey)
+		assert.Contains(t, err.Error(), "prefix")
+	})
+	t.Run("empty range should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewKeyRange("b", "a", "")
+		assert.ErrorIs(t, err, errEmptyKeyRange)
+
+		_, err = NewKeyRange("a", "a", "")
+		assert.ErrorIs(t, err, errEmptyKeyRange)
+
+		_, err = NewKeyRange("", "b", "c")
+		assert.ErrorIs(t, err, errEmptyKeyRange)
+	})
+	t.Run("no values should return the full range", func(t *testing.T) {
+		t.Parallel()
+
+		keyRange, err := NewKeyRange("", "", "")
+		assert.Nil(t, err)
+		assert.True(t, keyRange.IsFullRange())
+		assert.Equal(t, "all keys", keyRange.String())
+	})
+	t.Run("start and end should work", func(t *testing.T) {
+		t.Parallel()
+
+		keyRange, err := NewKeyRange("0x01", "b", "")
+		assert.Nil(t, err)
+		assert.Equal(t, KeyRange{Start: []byte{1}, End: []byte("b")}, keyRange)
+		assert.Equal(t, "[01, 62)", keyRange.String())
+
+		keyRange, err = NewKeyRange("a", "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "[61, end)", keyRange.String())
+	})
+	t.Run("prefix should narrow the range", func(t *testing.T) {
+		t.Parallel()
+
+		keyRange, err := NewKeyRange("", "", "ab")
+		assert.Nil(t, err)
+		assert.Equal(t, KeyRange{Start: []byte("ab"), End: []byte("ac")}, keyRange)
+
+		keyRange, err = NewKeyRange("abc", "zz", "ab")
+		assert.Nil(t, err)
+		assert.Equal(t, KeyRange{Start: []byte("abc"), End: []byte("ac")}, keyRange)
+
+		keyRange, err = NewKeyRange("a", "abd", "ab")
+		assert.Nil(t, err)
+		assert.Equal(t, KeyRange{Start: []byte("ab"), End: []byte("abd")}, keyRange)
+
+		keyRange, err = NewKeyRange("", "", "0xffff")
+		assert.Nil(t, err)
+		assert.Equal(t, KeyRange{Start: []byte{0xff, 0xff}}, keyRange)
+	})
+}
@@ -0,0 +1,304 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ArgsLoadHandler is the DTO used to create a new instance of type load handler
+type ArgsLoadHandler struct {
+	DBWrapperFactory DBWrapperFactory
+	ConflictResolver ConflictResolver
+	DBPath           string
+	DestParentDir    string
+	RecordFormat     string
+	KeyEncoding      string
+	ValueEncoding    string
+	CreateMissing    bool
+	DryRun           bool
+}
+
+type loadHandler struct {
+	dbWrapperFactory DBWrapperFactory
+	conflictResolver ConflictResolver
+	dbPath           string
+	destParentDir    string
+	recordFormat     string
+	keyEncoding      string
+	valueEncoding    string
+	createMissing    bool
+	dryRun           bool
+}
+
+type loadTarget struct {
+	path   string
+	db     DBWrapper
+	result dbResult
+}
+
+// NewLoadHandler creates a new instance of type load handler. The records are loaded in the provided DB or, if not
+// set, in the DB named by each record under the destination parent directory. The key and value encodings are used
+// for the records that do not hold their own encodings
+func NewLoadHandler(args ArgsLoadHandler) (*loadHandler, error) {
+	if check.IfNil(args.DBWrapperFactory) {
+		return nil, errNilDBWrapperFactory
+	}
+	if check.IfNil(args.ConflictResolver) {
+		return nil, errNilConflictResolver
+	}
+	if len(args.DBPath) > 0 && len(args.DestParentDir) > 0 {
+		return nil, fmt.Errorf("%w: the records are loaded either in a DB or under a destination parent directory", errIncompatibleOptions)
+	}
+	if len(args.DBPath) == 0 && len(args.DestParentDir) == 0 {
+		return nil, errNoDestinationParentDirectory
+	}
+	err := CheckRecordFormat(args.RecordFormat)
+	if err != nil {
+		return nil, err
+	}
+	_, err = decodeField("", args.KeyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the keys", err)
+	}
+	_, err = decodeField("", args.ValueEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the values", err)
+	}
+
+	return &loadHandler{
+		dbWrapperFactory: args.DBWrapperFactory,
+		conflictResolver: args.ConflictResolver,
+		dbPath:           args.DBPath,
+		destParentDir:    args.DestParentDir,
+		recordFormat:     args.RecordFormat,
+		keyEncoding:      args.KeyEncoding,
+		valueEncoding:    args.ValueEncoding,
+		createMissing:    args.CreateMissing,
+		dryRun:           args.DryRun,
+	}, nil
+}
+
+// Process reads the records and writes the keys missing from the destination DBs. When a key already holds a
+// different value, the conflict resolver decides if the value is overwritten. The destination DBs are opened when
+// their first record is read and are all closed at the end
+func (handler *loadHandler) Process(reader io.Reader) error {
+	records, err := newRecordReader(reader, handler.recordFormat)
+	if err != nil {
+		return err
+	}
+
+	targets := make(map[string]*loadTarget)
+	numRecords, errProcess := handler.loadRecords(records, targets)
+	errClose := closeLoadTargets(targets)
+	if errProcess != nil {
+		return errProcess
+	}
+	if errClose != nil {
+		return errClose
+	}
+
+	handler.logSummary(targets, numRecords)
+
+	return nil
+}
+
+func (handler *loadHandler) loadRecords(records recordReader, targets map[string]*loadTarget) (int, error) {
+	numRecords := 0
+	for {
+		record, err := records.nextRecord()
+		if err != nil {
+			return numRecords, err
+		}
+		if record == nil {
+			return numRecords, nil
+		}
+
+		numRecords++
+		err = handler.loadRecord(targets, record, numRecords)
+		if err != nil {
+			return numRecords, err
+		}
+	}
+}
+
+func closeLoadTargets(targets map[string]*loadTarget) error {
+	wrappers := make([]DBWrapper, 0, len(targets))
+	for _, target := range targets {
+		wrappers = append(wrappers, target.db)
+	}
+
+	return closeAll(wrappers)
+}
+
+func (handler *loadHandler) loadRecord(targets map[string]*loadTarget, record *dbRecord, index int) error {
+	key, value, err := handler.decodeRecord(record)
+	if err != nil {
+		return fmt.Errorf("%w, record %d", err, index)
+	}
+
+	dbPath, err := handler.targetPath(record)
+	if err != nil {
+		return fmt.Errorf("%w, record %d", err, index)
+	}
+
+	target, found := targets[dbPath]
+	if !found {
+		target, err = handler.openTarget(dbPath)
+		if err != nil {
+			return err
+		}
+		targets[dbPath] = target
+	}
+
+	existingValue, err := target.db.Get(key)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		target.result.numInserts++
+		target.result.numBytes += len(key) + len(value)
+		return handler.put(target, key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("%w, dest path %s", err, dbPath)
+	}
+	if bytes.Equal(existingValue, value) {
+		return nil
+	}
+
+	target.result.numConflicts++
+	shouldOverwrite, err := handler.conflictResolver.ShouldOverwrite(key, value, existingValue)
+	if err != nil {
+		return fmt.Errorf("%w, dest path %s", err, dbPath)
+	}
+
+	log.Debug("conflicting values found", "dest path", dbPath, "key", key, "overwrite", shouldOverwrite)
+	if !shouldOverwrite {
+		return nil
+	}
+
+	target.result.numOverwrites++
+	target.result.numBytes += len(key) + len(value)
+
+	return handler.put(target, key, value)
+}
+
+func (handler *loadHandler) decodeRecord(record *dbRecord) ([]byte, []byte, error) {
+	if record.Value == nil {
+		return nil, nil, fmt.Errorf("%w: missing value", errInvalidRecord)
+	}
+
+	key, err := decodeField(record.Key, handler.encoding(record.KeyEncoding, handler.keyEncoding))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s for the key", errInvalidRecord, err.Error())
+	}
+	value, err := decodeField(*record.Value, handler.encoding(record.ValueEncoding, handler.valueEncoding))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s for the value", errInvalidRecord, err.Error())
+	}
+
+	return key, value, nil
+}
+
+func (handler *loadHandler) encoding(recordEncoding string, defaultEncoding string) string {
+	if len(recordEncoding) == 0 {
+		return defaultEncoding
+	}
+
+	return recordEncoding
+}
+
+// targetPath returns the DB the record is loaded in. The DB named by the record must be found under the destination
+// parent directory
+func (handler *loadHandler) targetPath(record *dbRecord) (string, error) {
+	if len(handler.dbPath) > 0 {
+		return handler.dbPath, nil
+	}
+	if len(record.DB) == 0 {
+		return "", fmt.Errorf("%w: missing DB name", errInvalidRecord)
+	}
+	if !filepath.IsLocal(record.DB) {
+		return "", fmt.Errorf("%w: DB name %s is outside the destination parent directory", errInvalidRecord, record.DB)
+	}
+
+	return filepath.Join(handler.destParentDir, record.DB), nil
+}
+
+// openTarget opens the destination DB, creating it if missing and allowed. In dry-run mode, an existing DB is opened
+// in read-only mode and a DB that is about to be created is not touched, an empty DB being used instead
+func (handler *loadHandler) openTarget(dbPath string) (*loadTarget, error) {
+	target := &loadTarget{
+		path: dbPath,
+	}
+	target.result.created = !isLevelDBDirectory(dbPath)
+	if target.result.created && !handler.createMissing {
+		return nil, fmt.Errorf("%w, path %s", errNotALevelDB, dbPath)
+	}
+	if target.result.created && handler.dryRun {
+		target.db = newDisabledDBWrapper()
+		return target, nil
+	}
+
+	role := DestinationRole
+	if handler.dryRun {
+		role = SourceRole
+	}
+	wrapper, err := handler.dbWrapperFactory.Create(dbPath, role)
+	if err != nil {
+		return nil, err
+	}
+	err = wrapper.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("loading the records in", "path", dbPath, "created", target.result.created)
+	target.db = wrapper
+
+	return target, nil
+}
+
+func (handler *loadHandler) put(target *loadTarget, key []byte, value []byte) error {
+	if handler.dryRun {
+		return nil
+	}
+
+	err := target.db.Put(key, value)
+	if err != nil {
+		return fmt.Errorf("%w, dest path %s", err, target.path)
+	}
+
+	return nil
+}
+
+func (handler *loadHandler) logSummary(targets map[string]*loadTarget, numRecords int) {
+	paths := make([]string, 0, len(targets))
+	for dbPath := range targets {
+		paths = append(paths, dbPath)
+	}
+	sort.Strings(paths)
+
+	total := dbResult{}
+	numCreated := 0
+	for _, dbPath := range paths {
+		result := targets[dbPath].result
+		log.Info("summary", "dest path", dbPath, "missing info added", result.numInserts, "conflicts", result.numConflicts,
+			"overwritten", result.numOverwrites, "bytes", result.numBytes, "created", result.created)
+
+		total.numInserts += result.numInserts
+		total.numConflicts += result.numConflicts
+		total.numOverwrites += result.numOverwrites
+		total.numBytes += result.numBytes
+		if result.created {
+			numCreated++
+		}
+	}
+
+	log.Info("summary", "records", numRecords, "loaded DBs", len(paths), "created DBs", numCreated,
+		"missing info added", total.numInserts, "conflicts", total.numConflicts, "overwritten", total.numOverwrites,
+		"bytes", total.numBytes, "dry run", handler.dryRun)
+}
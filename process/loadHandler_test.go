@@ -0,0 +1,323 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func createMockArgsLoadHandler() ArgsLoadHandler {
+	return ArgsLoadHandler{
+		DBWrapperFactory: &dbWrapperFactoryStub{},
+		ConflictResolver: &testcommon.ConflictResolverStub{},
+		DBPath:           "db",
+		RecordFormat:     JSONLRecordFormat,
+		KeyEncoding:      UTF8Encoding,
+		ValueEncoding:    UTF8Encoding,
+	}
+}
+
+func createLoadTestDB(t *testing.T, dbPath string, keysAndValues ...string) {
+	wrapper := NewDBWrapper()
+	require.Nil(t, wrapper.Open(dbPath))
+	for i := 0; i < len(keysAndValues); i += 2 {
+		require.Nil(t, wrapper.Put([]byte(keysAndValues[i]), []byte(keysAndValues[i+1])))
+	}
+	require.Nil(t, wrapper.Close())
+}
+
+func readLoadTestDB(t *testing.T, dbPath string) map[string]string {
+	if !isLevelDBDirectory(dbPath) {
+		return nil
+	}
+
+	wrapper := NewDBWrapper()
+	require.Nil(t, wrapper.Open(dbPath))
+	result := make(map[string]string)
+	wrapper.RangeKeys(func(key []byte, val []byte) bool {
+		result[string(key)] = string(val)
+		return true
+	})
+	require.Nil(t, wrapper.Close())
+
+	return result
+}
+
+func TestNewLoadHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil DB wrapper factory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = nil
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilDBWrapperFactory, err)
+	})
+	t.Run("nil conflict resolver should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.ConflictResolver = nil
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNilConflictResolver, err)
+	})
+	t.Run("DB path and destination parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.DestParentDir = "dest"
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errIncompatibleOptions)
+	})
+	t.Run("no DB path and no destination parent directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.DBPath = ""
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.Equal(t, errNoDestinationParentDirectory, err)
+	})
+	t.Run("unknown record format should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.RecordFormat = "xml"
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownRecordFormat)
+	})
+	t.Run("auto key encoding should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.KeyEncoding = AutoEncoding
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownEncoding)
+		assert.Contains(t, err.Error(), "for the keys")
+	})
+	t.Run("unknown value encoding should error", func(t *testing.T) {
+		t.Parallel()
+
+		args := createMockArgsLoadHandler()
+		args.ValueEncoding = "binary"
+		handler, err := NewLoadHandler(args)
+
+		assert.Nil(t, handler)
+		assert.ErrorIs(t, err, errUnknownEncoding)
+		assert.Contains(t, err.Error(), "for the values")
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		handler, err := NewLoadHandler(createMockArgsLoadHandler())
+		assert.NotNil(t, handler)
+		assert.Nil(t, err)
+	})
+}
+
+func TestLoadHandler_Process(t *testing.T) {
+	t.Parallel()
+
+	records := `{"db":"A","key":"a1","value":"new"}
+{"db":"A","key":"6132","keyEncoding":"hex","value":"djI=","valueEncoding":"base64"}
+{"db":"sub/B","key":"b1","value":"v1"}
+`
+
+	t.Run("should add only the missing keys", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		createLoadTestDB(t, filepath.Join(destParentDir, "A"), "a1", "v1")
+		createLoadTestDB(t, filepath.Join(destParentDir, "sub/B"))
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.ConflictResolver, _ = NewConflictResolver(KeepDestinationPolicy)
+		args.DBPath = ""
+		args.DestParentDir = destParentDir
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"a1": "v1", "a2": "v2"}, readLoadTestDB(t, filepath.Join(destParentDir, "A")))
+		assert.Equal(t, map[string]string{"b1": "v1"}, readLoadTestDB(t, filepath.Join(destParentDir, "sub/B")))
+	})
+	t.Run("overwrite policy should overwrite the different values", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		createLoadTestDB(t, filepath.Join(destParentDir, "A"), "a1", "v1")
+		createLoadTestDB(t, filepath.Join(destParentDir, "sub/B"))
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.DBPath = ""
+		args.DestParentDir = destParentDir
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"a1": "new", "a2": "v2"}, readLoadTestDB(t, filepath.Join(destParentDir, "A")))
+	})
+	t.Run("missing DB should error", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		createLoadTestDB(t, filepath.Join(destParentDir, "A"))
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.DBPath = ""
+		args.DestParentDir = destParentDir
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.ErrorIs(t, err, errNotALevelDB)
+		assert.Nil(t, readLoadTestDB(t, filepath.Join(destParentDir, "sub/B")))
+	})
+	t.Run("create missing should create the DB in the provided path", func(t *testing.T) {
+		t.Parallel()
+
+		dbPath := filepath.Join(t.TempDir(), "fixture")
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.DBPath = dbPath
+		args.RecordFormat = CSVRecordFormat
+		args.KeyEncoding = HexEncoding
+		args.CreateMissing = true
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString("key,value\n6b31,v1\n6b32,\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"k1": "v1", "k2": ""}, readLoadTestDB(t, dbPath))
+	})
+	t.Run("dry run should not change the DBs", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		createLoadTestDB(t, filepath.Join(destParentDir, "A"), "a1", "v1")
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.DBPath = ""
+		args.DestParentDir = destParentDir
+		args.CreateMissing = true
+		args.DryRun = true
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"a1": "v1"}, readLoadTestDB(t, filepath.Join(destParentDir, "A")))
+		assert.Nil(t, readLoadTestDB(t, filepath.Join(destParentDir, "sub/B")))
+	})
+	t.Run("dry run should open the existing DBs in read-only mode", func(t *testing.T) {
+		t.Parallel()
+
+		dbPath := filepath.Join(t.TempDir(), "A")
+		createLoadTestDB(t, dbPath, "a1", "v1")
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				assert.Equal(t, dbPath, path)
+				assert.Equal(t, SourceRole, role)
+				return NewDBWrapperFactory().Create(path, role)
+			},
+		}
+		args.ConflictResolver, _ = NewConflictResolver(OverwriteWithSourcePolicy)
+		args.DBPath = dbPath
+		args.DryRun = true
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"a1": "v1"}, readLoadTestDB(t, dbPath))
+	})
+	t.Run("close error should error", func(t *testing.T) {
+		t.Parallel()
+
+		dbPath := filepath.Join(t.TempDir(), "A")
+		createLoadTestDB(t, dbPath)
+
+		expectedErr := errors.New("expected error")
+		numClosed := 0
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = &dbWrapperFactoryStub{
+			createCalled: func(path string, role DBRole) (DBWrapper, error) {
+				return &testcommon.DBWrapperStub{
+					GetCalled: func(key []byte) ([]byte, error) {
+						return nil, leveldb.ErrNotFound
+					},
+					CloseCalled: func() error {
+						numClosed++
+						return expectedErr
+					},
+				}, nil
+			},
+		}
+		args.DBPath = dbPath
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, 1, numClosed)
+	})
+	t.Run("fail policy should error on conflicts", func(t *testing.T) {
+		t.Parallel()
+
+		destParentDir := t.TempDir()
+		createLoadTestDB(t, filepath.Join(destParentDir, "A"), "a1", "v1")
+
+		args := createMockArgsLoadHandler()
+		args.DBWrapperFactory = NewDBWrapperFactory()
+		args.ConflictResolver, _ = NewConflictResolver(FailPolicy)
+		args.DBPath = ""
+		args.DestParentDir = destParentDir
+		handler, _ := NewLoadHandler(args)
+
+		err := handler.Process(bytes.NewBufferString(records))
+		assert.ErrorIs(t, err, errConflictingValuesFound)
+	})
+	t.Run("invalid records should error", func(t *testing.T) {
+		t.Parallel()
+
+		testCases := map[string]string{
+			"missing value":                `{"db":"A","key":"k1"}`,
+			"missing DB name":              `{"key":"k1","value":"v1"}`,
+			"outside the destination":      `{"db":"../A","key":"k1","value":"v1"}`,
+			"invalid hex data for the key": `{"db":"A","key":"zz","keyEncoding":"hex","value":"v1"}`,
+			"unknown encoding":             `{"db":"A","key":"k1","value":"v1","valueEncoding":"auto"}`,
+		}
+		for name, record := range testCases {
+			args := createMockArgsLoadHandler()
+			args.DBPath = ""
+			args.DestParentDir = t.TempDir()
+			handler, _ := NewLoadHandler(args)
+
+			err := handler.Process(bytes.NewBufferString(record))
+			assert.ErrorIs(t, err, errInvalidRecord, name)
+			assert.Contains(t, err.Error(), "record 1", name)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+type lockChecker struct{}
+
+// NewLockChecker creates a new instance of type lockChecker
+func NewLockChecker() *lockChecker {
+	return &lockChecker{}
+}
+
+// IsLocked returns true if the level DB from the provided path is held by another process (or another opened
+// instance). Directories that are not level DBs are never considered locked. The check is done by trying to acquire
+// a shared lock on the DB's LOCK file, which is released right away, so no DB file is changed
+func (checker *lockChecker) IsLocked(path string) (bool, error) {
+	_, err := os.Stat(filepath.Join(path, currentFileName))
+	if err != nil {
+		return false, nil
+	}
+
+	stor, err := storage.OpenFile(path, true)
+	if err == nil {
+		return false, stor.Close()
+	}
+	if errors.Is(err, syscall.EWOULDBLOCK) || errors.Is(err, syscall.EAGAIN) {
+		return true, nil
+	}
+
+	return false, err
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (checker *lockChecker) IsInterfaceNil() bool {
+	return checker == nil
+}
@@ -0,0 +1,65 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockChecker_IsLocked(t *testing.T) {
+	t.Parallel()
+
+	t.Run("directory that is not a DB should not be locked", func(t *testing.T) {
+		t.Parallel()
+
+		checker := NewLockChecker()
+		isLocked, err := checker.IsLocked(t.TempDir())
+		assert.Nil(t, err)
+		assert.False(t, isLocked)
+	})
+	t.Run("closed DB should not be locked", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		wrapper := NewDBWrapper()
+		require.Nil(t, wrapper.Open(dir))
+		require.Nil(t, wrapper.Close())
+
+		checker := NewLockChecker()
+		isLocked, err := checker.IsLocked(dir)
+		assert.Nil(t, err)
+		assert.False(t, isLocked)
+
+		// the check should have released the lock
+		require.Nil(t, wrapper.Open(dir))
+		require.Nil(t, wrapper.Close())
+	})
+	t.Run("opened DB should be locked", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		wrapper := NewDBWrapper()
+		require.Nil(t, wrapper.Open(dir))
+
+		checker := NewLockChecker()
+		isLocked, err := checker.IsLocked(dir)
+		assert.Nil(t, err)
+		assert.True(t, isLocked)
+
+		require.Nil(t, wrapper.Close())
+		isLocked, err = checker.IsLocked(dir)
+		assert.Nil(t, err)
+		assert.False(t, isLocked)
+	})
+}
+
+func TestLockChecker_IsInterfaceNil(t *testing.T) {
+	t.Parallel()
+
+	var instance *lockChecker
+	assert.True(t, instance.IsInterfaceNil())
+
+	instance = &lockChecker{}
+	assert.False(t, instance.IsInterfaceNil())
+}
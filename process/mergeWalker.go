@@ -0,0 +1,102 @@
+package process
+
+import (
+	"bytes"
+
+	"iulianpascalau/level-db-copy-go/common"
+)
+
+// mergedEntry holds a key found in at least one of the two walked DBs, together with its values
+type mergedEntry struct {
+	key       []byte
+	srcValue  []byte
+	destValue []byte
+	inSrc     bool
+	inDest    bool
+}
+
+// walkMerged walks two sorted iterators in a single pass (merge-join), calling the handler once for each distinct
+// key, in ascending order. The walk stops when the handler returns false. The iterators are not released
+func walkMerged(src common.DBIterator, dest common.DBIterator, handler func(entry *mergedEntry) bool) error {
+	return walkMergedFanOut(src, []common.DBIterator{dest}, func(entries []*mergedEntry) bool {
+		return handler(entries[0])
+	})
+}
+
+// walkMergedFanOut walks the source iterator side by side with several destination iterators in a single pass,
+// calling the handler once for each distinct key found in any of them, in ascending order. The handler receives one
+// entry for each destination iterator, in the provided order. The walk stops when the handler returns false.
+// The iterators are not released
+func walkMergedFanOut(src common.DBIterator, dests []common.DBIterator, handler func(entries []*mergedEntry) bool) error {
+	hasSrc := src.Next()
+	hasDests := make([]bool, len(dests))
+	for index, dest := range dests {
+		hasDests[index] = dest.Next()
+	}
+
+	for {
+		var key []byte
+		found := hasSrc
+		if hasSrc {
+			key = src.Key()
+		}
+		for index, dest := range dests {
+			if !hasDests[index] {
+				continue
+			}
+			destKey := dest.Key()
+			if !found || bytes.Compare(destKey, key) < 0 {
+				key = destKey
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+
+		inSrc := hasSrc && bytes.Equal(src.Key(), key)
+		var srcValue []byte
+		if inSrc {
+			srcValue = src.Value()
+		}
+		entries := make([]*mergedEntry, len(dests))
+		for index, dest := range dests {
+			entry := &mergedEntry{
+				key:      key,
+				srcValue: srcValue,
+				inSrc:    inSrc,
+			}
+			if hasDests[index] && bytes.Equal(dest.Key(), key) {
+				entry.destValue = dest.Value()
+				entry.inDest = true
+			}
+			entries[index] = entry
+		}
+
+		if !handler(entries) {
+			break
+		}
+
+		if inSrc {
+			hasSrc = src.Next()
+		}
+		for index, entry := range entries {
+			if entry.inDest {
+				hasDests[index] = dests[index].Next()
+			}
+		}
+	}
+
+	err := src.Error()
+	if err != nil {
+		return err
+	}
+	for _, dest := range dests {
+		err = dest.Error()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
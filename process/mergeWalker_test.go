@@ -0,0 +1,134 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/common"
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createIterator(keysAndValues ...string) *testcommon.InMemoryIterator {
+	keys := make([][]byte, 0, len(keysAndValues)/2)
+	values := make([][]byte, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		keys = append(keys, []byte(keysAndValues[i]))
+		values = append(values, []byte(keysAndValues[i+1]))
+	}
+
+	return testcommon.NewInMemoryIterator(keys, values)
+}
+
+func TestWalkMerged(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should walk all the keys in order", func(t *testing.T) {
+		t.Parallel()
+
+		src := createIterator("a", "1", "b", "2", "d", "4", "f", "6")
+		dest := createIterator("b", "2", "c", "3", "d", "x", "g", "7")
+
+		entries := make([]string, 0)
+		err := walkMerged(src, dest, func(entry *mergedEntry) bool {
+			entries = append(entries, fmt.Sprintf("%s:%v/%v:%s/%s", entry.key, entry.inSrc, entry.inDest, entry.srcValue, entry.destValue))
+			return true
+		})
+		assert.Nil(t, err)
+
+		expectedEntries := []string{
+			"a:true/false:1/",
+			"b:true/true:2/2",
+			"c:false/true:/3",
+			"d:true/true:4/x",
+			"f:true/false:6/",
+			"g:false/true:/7",
+		}
+		assert.Equal(t, expectedEntries, entries)
+	})
+	t.Run("empty iterators should not call the handler", func(t *testing.T) {
+		t.Parallel()
+
+		err := walkMerged(createIterator(), createIterator(), func(entry *mergedEntry) bool {
+			assert.Fail(t, "should have not called the handler")
+			return true
+		})
+		assert.Nil(t, err)
+	})
+	t.Run("should stop when the handler returns false", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		err := walkMerged(createIterator("a", "1", "b", "2"), createIterator("c", "3"), func(entry *mergedEntry) bool {
+			numCalls++
+			return numCalls < 2
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, numCalls)
+	})
+	t.Run("iterator errors should be returned", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		err := walkMerged(testcommon.NewInMemoryIteratorWithError(expectedErr), createIterator(), func(entry *mergedEntry) bool {
+			return true
+		})
+		assert.Equal(t, expectedErr, err)
+
+		err = walkMerged(createIterator(), testcommon.NewInMemoryIteratorWithError(expectedErr), func(entry *mergedEntry) bool {
+			return true
+		})
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestWalkMergedFanOut(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should walk all the keys in order, with an entry for each destination", func(t *testing.T) {
+		t.Parallel()
+
+		src := createIterator("a", "1", "c", "3", "e", "5")
+		dests := []common.DBIterator{
+			createIterator("a", "1", "b", "2"),
+			createIterator("c", "x", "d", "4"),
+			createIterator(),
+		}
+
+		entries := make([]string, 0)
+		err := walkMergedFanOut(src, dests, func(destEntries []*mergedEntry) bool {
+			assert.Equal(t, 3, len(destEntries))
+			line := fmt.Sprintf("%s:%v:%s", destEntries[0].key, destEntries[0].inSrc, destEntries[0].srcValue)
+			for _, entry := range destEntries {
+				line += fmt.Sprintf("/%v:%s", entry.inDest, entry.destValue)
+			}
+			entries = append(entries, line)
+			return true
+		})
+		assert.Nil(t, err)
+
+		expectedEntries := []string{
+			"a:true:1/true:1/false:/false:",
+			"b:false:/true:2/false:/false:",
+			"c:true:3/false:/true:x/false:",
+			"d:false:/false:/true:4/false:",
+			"e:true:5/false:/false:/false:",
+		}
+		assert.Equal(t, expectedEntries, entries)
+	})
+	t.Run("destination iterator error should be returned", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		dests := []common.DBIterator{
+			createIterator("a", "1"),
+			testcommon.NewInMemoryIteratorWithError(expectedErr),
+		}
+		err := walkMergedFanOut(createIterator("a", "1"), dests, func(destEntries []*mergedEntry) bool {
+			return true
+		})
+		assert.Equal(t, expectedErr, err)
+	})
+}
@@ -0,0 +1,54 @@
+package process
+
+import (
+	"fmt"
+	"path"
+)
+
+// nameFilter selects the DBs by their relative names using glob patterns. A pattern matches either the whole
+// relative name (e.g. Epoch_*/Shard_0/MiniBlocks) or its last element (e.g. MiniBlocks)
+type nameFilter struct {
+	includes []string
+	excludes []string
+}
+
+func newNameFilter(includes []string, excludes []string) (*nameFilter, error) {
+	for _, pattern := range append(append([]string{}, includes...), excludes...) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s", errInvalidFilterPattern, pattern, err.Error())
+		}
+	}
+
+	return &nameFilter{
+		includes: includes,
+		excludes: excludes,
+	}, nil
+}
+
+// isSelected returns true if the name matches at least one include pattern (or no include pattern was provided)
+// and does not match any exclude pattern
+func (filter *nameFilter) isSelected(name string) bool {
+	if len(filter.includes) > 0 && !matchesAny(filter.includes, name) {
+		return false
+	}
+
+	return !matchesAny(filter.excludes, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	baseName := path.Base(name)
+	for _, pattern := range patterns {
+		matched, _ := path.Match(pattern, name)
+		if matched {
+			return true
+		}
+
+		matched, _ = path.Match(pattern, baseName)
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,52 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNameFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid include pattern should error", func(t *testing.T) {
+		t.Parallel()
+
+		filter, err := newNameFilter([]string{"Mini[Blocks"}, nil)
+		assert.Nil(t, filter)
+		assert.ErrorIs(t, err, errInvalidFilterPattern)
+		assert.Contains(t, err.Error(), "Mini[Blocks")
+	})
+	t.Run("invalid exclude pattern should error", func(t *testing.T) {
+		t.Parallel()
+
+		filter, err := newNameFilter(nil, []string{"Trie\\"})
+		assert.Nil(t, filter)
+		assert.ErrorIs(t, err, errInvalidFilterPattern)
+	})
+	t.Run("no patterns should select everything", func(t *testing.T) {
+		t.Parallel()
+
+		filter, err := newNameFilter(nil, nil)
+		assert.Nil(t, err)
+		assert.True(t, filter.isSelected("MiniBlocks"))
+		assert.True(t, filter.isSelected("Epoch_1/Shard_0/TrieEpochRootHash"))
+	})
+	t.Run("should apply the include and exclude patterns", func(t *testing.T) {
+		t.Parallel()
+
+		filter, err := newNameFilter(
+			[]string{"MiniBlocks", "BlockHeaders", "Static/*/*Trie"},
+			[]string{"Epoch_2/*/*", "PeerAccountsTrie"},
+		)
+		assert.Nil(t, err)
+
+		assert.True(t, filter.isSelected("MiniBlocks"))
+		assert.True(t, filter.isSelected("Epoch_1/Shard_0/MiniBlocks"))
+		assert.True(t, filter.isSelected("Epoch_1/Shard_0/BlockHeaders"))
+		assert.True(t, filter.isSelected("Static/Shard_0/AccountsTrie"))
+		assert.False(t, filter.isSelected("Static/Shard_0/PeerAccountsTrie"))
+		assert.False(t, filter.isSelected("Epoch_1/Shard_0/TrieEpochRootHash"))
+		assert.False(t, filter.isSelected("Epoch_2/Shard_0/MiniBlocks"))
+	})
+}
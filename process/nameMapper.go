@@ -0,0 +1,127 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	mappingSeparator = "="
+	mappingWildcard  = "*"
+	mappingComment   = "#"
+)
+
+// mappingRule pairs the source DBs matching a pattern with the destination DBs. Each wildcard from the destination
+// pattern is replaced with the text matched by the wildcard from the same position in the source pattern
+type mappingRule struct {
+	source          *regexp.Regexp
+	destinationPart []string
+}
+
+type nameMapper struct {
+	rules []*mappingRule
+}
+
+func newNameMapper(rules []string) (*nameMapper, error) {
+	mapper := &nameMapper{
+		rules: make([]*mappingRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		parsedRule, err := parseMappingRule(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		mapper.rules = append(mapper.rules, parsedRule)
+	}
+
+	return mapper, nil
+}
+
+// parseMappingRule parses a rule like Epoch_1200/*=Epoch_1201/*
+func parseMappingRule(rule string) (*mappingRule, error) {
+	parts := strings.Split(rule, mappingSeparator)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w %q, expected format source=destination", errInvalidMappingRule, rule)
+	}
+
+	srcPattern := strings.TrimSpace(parts[0])
+	destPattern := strings.TrimSpace(parts[1])
+	if len(srcPattern) == 0 || len(destPattern) == 0 {
+		return nil, fmt.Errorf("%w %q, empty source or destination", errInvalidMappingRule, rule)
+	}
+
+	srcParts := strings.Split(srcPattern, mappingWildcard)
+	destParts := strings.Split(destPattern, mappingWildcard)
+	if len(destParts) > len(srcParts) {
+		return nil, fmt.Errorf("%w %q, the destination has more wildcards than the source", errInvalidMappingRule, rule)
+	}
+
+	quotedParts := make([]string, 0, len(srcParts))
+	for _, part := range srcParts {
+		quotedParts = append(quotedParts, regexp.QuoteMeta(part))
+	}
+
+	return &mappingRule{
+		source:          regexp.MustCompile("^" + strings.Join(quotedParts, "(.*)") + "$"),
+		destinationPart: destParts,
+	}, nil
+}
+
+func (rule *mappingRule) apply(name string) (string, bool) {
+	matches := rule.source.FindStringSubmatch(name)
+	if matches == nil {
+		return "", false
+	}
+
+	builder := strings.Builder{}
+	for i, part := range rule.destinationPart {
+		if i > 0 {
+			builder.WriteString(matches[i])
+		}
+		builder.WriteString(part)
+	}
+
+	return builder.String(), true
+}
+
+// destinationName returns the destination name produced by the first matching rule. If no rule matches,
+// the name is returned unchanged
+func (mapper *nameMapper) destinationName(sourceName string) string {
+	for _, rule := range mapper.rules {
+		destName, matched := rule.apply(sourceName)
+		if matched {
+			return destName
+		}
+	}
+
+	return sourceName
+}
+
+// ReadMappingFile reads the mapping rules from a file holding one source=destination rule per line.
+// The empty lines and the ones starting with # are ignored
+func ReadMappingFile(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	rules := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, mappingComment) {
+			continue
+		}
+
+		rules = append(rules, line)
+	}
+
+	return rules, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNameMapper(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid rules should error", func(t *testing.T) {
+		t.Parallel()
+
+		invalidRules := []string{
+			"Epoch_1200",
+			"A=B=C",
+			"=Epoch_1201",
+			"Epoch_1200= ",
+			"Epoch_*=Epoch_*/*",
+		}
+		for _, rule := range invalidRules {
+			mapper, err := newNameMapper([]string{rule})
+			assert.Nil(t, mapper)
+			assert.ErrorIs(t, err, errInvalidMappingRule, rule)
+		}
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		mapper, err := newNameMapper([]string{
+			"Epoch_1200=Epoch_1201",
+			"Epoch_1300/*=Epoch_1301/*",
+			"*/Shard_*/OldUnit = */Shard_*/NewUnit",
+			"Static/*/Trie.*=Static/*/Trie",
+		})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "Epoch_1201", mapper.destinationName("Epoch_1200"))
+		assert.Equal(t, "Epoch_12001", mapper.destinationName("Epoch_12001"))
+		assert.Equal(t, "Epoch_1301/Shard_0/MiniBlocks", mapper.destinationName("Epoch_1300/Shard_0/MiniBlocks"))
+		assert.Equal(t, "Epoch_5/Shard_1/NewUnit", mapper.destinationName("Epoch_5/Shard_1/OldUnit"))
+		assert.Equal(t, "Static/Shard_0/Trie", mapper.destinationName("Static/Shard_0/Trie.old"))
+		assert.Equal(t, "Unmapped", mapper.destinationName("Unmapped"))
+	})
+	t.Run("first matching rule wins", func(t *testing.T) {
+		t.Parallel()
+
+		mapper, err := newNameMapper([]string{"A*=X", "AB=Y"})
+		assert.Nil(t, err)
+		assert.Equal(t, "X", mapper.destinationName("AB"))
+	})
+}
+
+func TestReadMappingFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing file should error", func(t *testing.T) {
+		t.Parallel()
+
+		rules, err := ReadMappingFile(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Nil(t, rules)
+		assert.NotNil(t, err)
+	})
+	t.Run("should skip the empty lines and the comments", func(t *testing.T) {
+		t.Parallel()
+
+		filePath := filepath.Join(t.TempDir(), "mapping.txt")
+		content := "# merge the backup epoch\nEpoch_1200=Epoch_1201\n\n  */OldUnit=*/NewUnit  \n"
+		err := os.WriteFile(filePath, []byte(content), 0600)
+		assert.Nil(t, err)
+
+		rules, err := ReadMappingFile(filePath)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Epoch_1200=Epoch_1201", "*/OldUnit=*/NewUnit"}, rules)
+	})
+}
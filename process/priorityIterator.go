@@ -0,0 +1,138 @@
+package process
+
+import (
+	"bytes"
+
+	"iulianpascalau/level-db-copy-go/common"
+)
+
+// priorityIterator merges several sorted iterators, in priority order, into a single sorted iterator. A key found
+// in more than one iterator is returned once, with the value from the iterator having the highest priority (the
+// lowest index). The provided handler is called for each key found with different values
+type priorityIterator struct {
+	iterators      []common.DBIterator
+	currentKeys    [][]byte
+	key            []byte
+	value          []byte
+	started        bool
+	onDifferentKey func(key []byte)
+}
+
+// newSourcesIterator returns an iterator over the provided key range of the source DBs, merged in priority order
+func newSourcesIterator(srcs []DBWrapper, startKey []byte, endKey []byte, onDifferentKey func(key []byte)) (common.DBIterator, error) {
+	if len(srcs) == 1 {
+		return srcs[0].NewIterator(startKey, endKey)
+	}
+
+	iterators := make([]common.DBIterator, 0, len(srcs))
+	for _, src := range srcs {
+		iterator, err := src.NewIterator(startKey, endKey)
+		if err != nil {
+			for _, created := range iterators {
+				created.Release()
+			}
+			return nil, err
+		}
+
+		iterators = append(iterators, iterator)
+	}
+
+	return newPriorityIterator(iterators, onDifferentKey), nil
+}
+
+func newPriorityIterator(iterators []common.DBIterator, onDifferentKey func(key []byte)) *priorityIterator {
+	return &priorityIterator{
+		iterators:      iterators,
+		currentKeys:    make([][]byte, len(iterators)),
+		onDifferentKey: onDifferentKey,
+	}
+}
+
+// Seek moves the iterator on the first key that is greater or equal to the provided key. Returns false if
+// there is no such key
+func (it *priorityIterator) Seek(key []byte) bool {
+	for index, iterator := range it.iterators {
+		it.move(index, iterator.Seek(key))
+	}
+	it.started = true
+
+	return it.selectCurrent()
+}
+
+// Next moves the iterator on the next key. Returns false if there are no more keys
+func (it *priorityIterator) Next() bool {
+	for index, iterator := range it.iterators {
+		if !it.started || (it.currentKeys[index] != nil && bytes.Equal(it.currentKeys[index], it.key)) {
+			it.move(index, iterator.Next())
+		}
+	}
+	it.started = true
+
+	return it.selectCurrent()
+}
+
+func (it *priorityIterator) move(index int, isValid bool) {
+	it.currentKeys[index] = nil
+	if isValid {
+		it.currentKeys[index] = it.iterators[index].Key()
+	}
+}
+
+func (it *priorityIterator) selectCurrent() bool {
+	it.key = nil
+	it.value = nil
+	winner := -1
+	for index, key := range it.currentKeys {
+		if key == nil {
+			continue
+		}
+		if winner < 0 || bytes.Compare(key, it.key) < 0 {
+			winner = index
+			it.key = key
+		}
+	}
+	if winner < 0 {
+		return false
+	}
+
+	it.value = it.iterators[winner].Value()
+	for index := winner + 1; index < len(it.iterators); index++ {
+		if !bytes.Equal(it.currentKeys[index], it.key) {
+			continue
+		}
+		if !bytes.Equal(it.iterators[index].Value(), it.value) && it.onDifferentKey != nil {
+			it.onDifferentKey(it.key)
+		}
+	}
+
+	return true
+}
+
+// Key returns the current key
+func (it *priorityIterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key, from the iterator with the highest priority holding it
+func (it *priorityIterator) Value() []byte {
+	return it.value
+}
+
+// Error returns the first iteration error, if any
+func (it *priorityIterator) Error() error {
+	for _, iterator := range it.iterators {
+		err := iterator.Error()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Release releases all the merged iterators
+func (it *priorityIterator) Release() {
+	for _, iterator := range it.iterators {
+		iterator.Release()
+	}
+}
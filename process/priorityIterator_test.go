@@ -0,0 +1,134 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"iulianpascalau/level-db-copy-go/common"
+	"iulianpascalau/level-db-copy-go/testcommon"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(iterator common.DBIterator) []string {
+	entries := make([]string, 0)
+	for iterator.Next() {
+		entries = append(entries, fmt.Sprintf("%s:%s", iterator.Key(), iterator.Value()))
+	}
+
+	return entries
+}
+
+func TestPriorityIterator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should merge the keys, the first iterator winning", func(t *testing.T) {
+		t.Parallel()
+
+		differentKeys := make([]string, 0)
+		iterator := newPriorityIterator([]common.DBIterator{
+			createIterator("b", "1", "d", "1"),
+			createIterator("a", "2", "b", "2", "c", "2"),
+			createIterator("b", "1", "c", "3", "e", "3"),
+		}, func(key []byte) {
+			differentKeys = append(differentKeys, string(key))
+		})
+
+		expectedEntries := []string{"a:2", "b:1", "c:2", "d:1", "e:3"}
+		assert.Equal(t, expectedEntries, readAll(iterator))
+		assert.Equal(t, []string{"b", "c"}, differentKeys)
+		assert.False(t, iterator.Next())
+		assert.Nil(t, iterator.Key())
+		assert.Nil(t, iterator.Error())
+	})
+	t.Run("seek should move all the iterators", func(t *testing.T) {
+		t.Parallel()
+
+		iterator := newPriorityIterator([]common.DBIterator{
+			createIterator("a", "1", "d", "1"),
+			createIterator("b", "2", "c", "2", "d", "2"),
+		}, nil)
+
+		assert.True(t, iterator.Seek([]byte("bb")))
+		assert.Equal(t, []byte("c"), iterator.Key())
+		assert.Equal(t, []byte("2"), iterator.Value())
+		assert.Equal(t, []string{"d:1"}, readAll(iterator))
+		assert.False(t, iterator.Seek([]byte("e")))
+	})
+	t.Run("should return the first error and release all the iterators", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		first := createIterator("a", "1")
+		second := testcommon.NewInMemoryIteratorWithError(expectedErr)
+		iterator := newPriorityIterator([]common.DBIterator{first, second}, nil)
+
+		assert.Equal(t, []string{"a:1"}, readAll(iterator))
+		assert.Equal(t, expectedErr, iterator.Error())
+
+		iterator.Release()
+		assert.True(t, first.IsReleased())
+		assert.True(t, second.IsReleased())
+	})
+}
+
+func TestNewSourcesIterator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("single source should return its iterator", func(t *testing.T) {
+		t.Parallel()
+
+		expectedIterator := createIterator("a", "1")
+		src := &testcommon.DBWrapperStub{
+			NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				assert.Equal(t, []byte("a"), startKey)
+				assert.Equal(t, []byte("b"), endKey)
+				return expectedIterator, nil
+			},
+		}
+
+		iterator, err := newSourcesIterator([]DBWrapper{src}, []byte("a"), []byte("b"), nil)
+		assert.Nil(t, err)
+		assert.True(t, iterator == expectedIterator)
+	})
+	t.Run("iterator creation error should release the created iterators", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		created := createIterator("a", "1")
+		first := &testcommon.DBWrapperStub{
+			NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return created, nil
+			},
+		}
+		second := &testcommon.DBWrapperStub{
+			NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return nil, expectedErr
+			},
+		}
+
+		iterator, err := newSourcesIterator([]DBWrapper{first, second}, nil, nil, nil)
+		assert.Nil(t, iterator)
+		assert.Equal(t, expectedErr, err)
+		assert.True(t, created.IsReleased())
+	})
+	t.Run("should merge the sources", func(t *testing.T) {
+		t.Parallel()
+
+		first := &testcommon.DBWrapperStub{
+			NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return createIterator("a", "1", "c", "1"), nil
+			},
+		}
+		second := &testcommon.DBWrapperStub{
+			NewIteratorCalled: func(startKey []byte, endKey []byte) (common.DBIterator, error) {
+				return createIterator("b", "2", "c", "2"), nil
+			},
+		}
+
+		iterator, err := newSourcesIterator([]DBWrapper{first, second}, nil, nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a:1", "b:2", "c:1"}, readAll(iterator))
+	})
+}
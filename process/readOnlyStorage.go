@@ -0,0 +1,146 @@
+package process
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb/journal"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// readOnlyStorage is a read-only level DB file storage that presents all the journal files as a single journal,
+// rebuilt in memory. The level DB read-only recovery fails with EOF as soon as it has to replay a second journal file
+// (the journal reader reset returns the end of the previous file as an error), which happens on any DB closed with a
+// rotated journal. The batches of the journals already flushed to the tables are skipped by the replay, as their
+// sequence numbers are behind the DB sequence number
+type readOnlyStorage struct {
+	storage.Storage
+	journal storage.FileDesc
+	content []byte
+}
+
+func newReadOnlyStorage(path string) (*readOnlyStorage, error) {
+	stor, err := storage.OpenFile(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnlyStor := &readOnlyStorage{
+		Storage: stor,
+	}
+	err = readOnlyStor.mergeJournals()
+	if err != nil {
+		_ = stor.Close()
+		return nil, err
+	}
+
+	return readOnlyStor, nil
+}
+
+// mergeJournals rewrites the records of all the journal files, in file number order, in a single journal named as
+// the newest journal file. Nothing is done if there is at most one journal file
+func (stor *readOnlyStorage) mergeJournals() error {
+	fds, err := stor.Storage.List(storage.TypeJournal)
+	if err != nil {
+		return err
+	}
+	if len(fds) < 2 {
+		return nil
+	}
+	sort.Slice(fds, func(i, j int) bool {
+		return fds[i].Num < fds[j].Num
+	})
+
+	buff := &bytes.Buffer{}
+	writer := journal.NewWriter(buff)
+	for _, fd := range fds {
+		err = stor.copyJournal(fd, writer)
+		if err != nil {
+			return err
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	stor.journal = fds[len(fds)-1]
+	stor.content = buff.Bytes()
+
+	return nil
+}
+
+// copyJournal copies the records of a journal file. As the level DB replay, the corrupted records are dropped
+func (stor *readOnlyStorage) copyJournal(fd storage.FileDesc, writer *journal.Writer) error {
+	file, err := stor.Storage.Open(fd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := journal.NewReader(file, nil, false, true)
+	for {
+		record, errNext := reader.Next()
+		if errNext == io.EOF {
+			return nil
+		}
+		if errNext != nil {
+			return errNext
+		}
+
+		data, errRead := io.ReadAll(record)
+		if errRead == io.ErrUnexpectedEOF {
+			continue
+		}
+		if errRead != nil {
+			return errRead
+		}
+
+		recordWriter, errWrite := writer.Next()
+		if errWrite != nil {
+			return errWrite
+		}
+		_, errWrite = recordWriter.Write(data)
+		if errWrite != nil {
+			return errWrite
+		}
+	}
+}
+
+// List lists the files of the provided types, the journal files being replaced by the merged journal
+func (stor *readOnlyStorage) List(ft storage.FileType) ([]storage.FileDesc, error) {
+	fds, err := stor.Storage.List(ft)
+	if err != nil || stor.content == nil || ft&storage.TypeJournal == 0 {
+		return fds, err
+	}
+
+	listed := make([]storage.FileDesc, 0, len(fds))
+	for _, fd := range fds {
+		if fd.Type != storage.TypeJournal {
+			listed = append(listed, fd)
+		}
+	}
+
+	return append(listed, stor.journal), nil
+}
+
+// Open opens the provided file, the merged journal being read from memory
+func (stor *readOnlyStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	if stor.content == nil || fd != stor.journal {
+		return stor.Storage.Open(fd)
+	}
+
+	return &memoryReader{Reader: bytes.NewReader(stor.content)}, nil
+}
+
+type memoryReader struct {
+	*bytes.Reader
+}
+
+// Close does nothing
+func (reader *memoryReader) Close() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package process
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+func TestNewReadOnlyStorage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		stor, err := newReadOnlyStorage(filepath.Join(t.TempDir(), "missing"))
+		assert.Nil(t, stor)
+		assert.NotNil(t, err)
+	})
+	t.Run("single journal should not be merged", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		wrapper := NewDBWrapper()
+		require.Nil(t, wrapper.Open(dir))
+		require.Nil(t, wrapper.Put([]byte("key1"), []byte("value1")))
+		require.Nil(t, wrapper.Close())
+
+		stor, err := newReadOnlyStorage(dir)
+		require.Nil(t, err)
+		defer func() {
+			_ = stor.Close()
+		}()
+
+		assert.Nil(t, stor.content)
+		fds, err := stor.List(storage.TypeJournal)
+		assert.Nil(t, err)
+		require.Len(t, fds, 1)
+
+		reader, err := stor.Open(fds[0])
+		require.Nil(t, err)
+		_, isMemoryReader := reader.(*memoryReader)
+		assert.False(t, isMemoryReader)
+		assert.Nil(t, reader.Close())
+	})
+}
@@ -0,0 +1,330 @@
+package process
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// HexEncoding encodes the keys and values as hex strings
+	HexEncoding = "hex"
+	// Base64Encoding encodes the keys and values as standard base64 strings
+	Base64Encoding = "base64"
+	// UTF8Encoding writes the keys and values as UTF-8 text
+	UTF8Encoding = "utf8"
+	// AutoEncoding writes the printable UTF-8 keys and values as text and all the others as hex strings
+	AutoEncoding = "auto"
+
+	// JSONLRecordFormat writes one JSON object for each record, on separate lines
+	JSONLRecordFormat = "jsonl"
+	// CSVRecordFormat writes one CSV row for each record, after a header row
+	CSVRecordFormat = "csv"
+)
+
+var (
+	recordsHeader         = []string{"db", "key", "keyEncoding", "value", "valueEncoding"}
+	recordsKeysOnlyHeader = recordsHeader[:3]
+)
+
+// Encodings returns all the available key and value encodings
+func Encodings() []string {
+	return []string{HexEncoding, Base64Encoding, UTF8Encoding, AutoEncoding}
+}
+
+// CheckEncoding returns an error if the provided key and value encoding is not available
+func CheckEncoding(encoding string) error {
+	for _, availableEncoding := range Encodings() {
+		if encoding == availableEncoding {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, available encodings: %s", errUnknownEncoding, encoding, strings.Join(Encodings(), ", "))
+}
+
+// RecordFormats returns all the available record formats
+func RecordFormats() []string {
+	return []string{JSONLRecordFormat, CSVRecordFormat}
+}
+
+// CheckRecordFormat returns an error if the provided record format is not available
+func CheckRecordFormat(format string) error {
+	for _, availableFormat := range RecordFormats() {
+		if format == availableFormat {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, available formats: %s", errUnknownRecordFormat, format, strings.Join(RecordFormats(), ", "))
+}
+
+// dbRecord is a key-value pair of a DB, with the key and the value encoded as strings. The encoding of each field is
+// recorded, so the records can be decoded whatever encoding was chosen. A nil value means that only the key is held
+type dbRecord struct {
+	DB            string  `json:"db"`
+	Key           string  `json:"key"`
+	KeyEncoding   string  `json:"keyEncoding"`
+	Value         *string `json:"value,omitempty"`
+	ValueEncoding string  `json:"valueEncoding,omitempty"`
+}
+
+// encodeField encodes the data with the provided encoding and returns the encoding actually used, the auto encoding
+// being resolved to UTF-8 or hex
+func encodeField(data []byte, encoding string) (string, string, error) {
+	if encoding == AutoEncoding {
+		encoding = HexEncoding
+		if isPrintableText(data) {
+			encoding = UTF8Encoding
+		}
+	}
+
+	switch encoding {
+	case HexEncoding:
+		return hex.EncodeToString(data), encoding, nil
+	case Base64Encoding:
+		return base64.StdEncoding.EncodeToString(data), encoding, nil
+	case UTF8Encoding:
+		if !utf8.Valid(data) {
+			return "", "", fmt.Errorf("%w, hex data %s", errInvalidUTF8Data, hex.EncodeToString(data))
+		}
+		return string(data), encoding, nil
+	default:
+		return "", "", CheckEncoding(encoding)
+	}
+}
+
+// decodeField decodes the data encoded with the provided encoding. The auto encoding can not be decoded, the encoding
+// it resolved to being recorded instead
+func decodeField(data string, encoding string) ([]byte, error) {
+	switch encoding {
+	case HexEncoding:
+		return hex.DecodeString(data)
+	case Base64Encoding:
+		return base64.StdEncoding.DecodeString(data)
+	case UTF8Encoding:
+		return []byte(data), nil
+	default:
+		return nil, fmt.Errorf("%w %q, available encodings for reading: %s, %s, %s", errUnknownEncoding, encoding,
+			HexEncoding, Base64Encoding, UTF8Encoding)
+	}
+}
+
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newDBRecord encodes the key and, if provided, the value of a DB record
+func newDBRecord(db string, key []byte, value []byte, keyEncoding string, valueEncoding string, keysOnly bool) (*dbRecord, error) {
+	encodedKey, usedKeyEncoding, err := encodeField(key, keyEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for a key of DB %s", err, db)
+	}
+
+	record := &dbRecord{
+		DB:          db,
+		Key:         encodedKey,
+		KeyEncoding: usedKeyEncoding,
+	}
+	if keysOnly {
+		return record, nil
+	}
+
+	encodedValue, usedValueEncoding, err := encodeField(value, valueEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w for the value of key %s, DB %s", err, hex.EncodeToString(key), db)
+	}
+	record.Value = &encodedValue
+	record.ValueEncoding = usedValueEncoding
+
+	return record, nil
+}
+
+// recordWriter writes the DB records in one of the available record formats
+type recordWriter interface {
+	writeRecord(record *dbRecord) error
+	flush() error
+}
+
+func newRecordWriter(writer io.Writer, format string, keysOnly bool) (recordWriter, error) {
+	switch format {
+	case JSONLRecordFormat:
+		encoder := json.NewEncoder(writer)
+		encoder.SetEscapeHTML(false)
+		return &jsonlRecordWriter{encoder: encoder}, nil
+	case CSVRecordFormat:
+		header := recordsHeader
+		if keysOnly {
+			header = recordsKeysOnlyHeader
+		}
+
+		csvWriter := csv.NewWriter(writer)
+		err := csvWriter.Write(header)
+		if err != nil {
+			return nil, err
+		}
+		return &csvRecordWriter{writer: csvWriter}, nil
+	default:
+		return nil, CheckRecordFormat(format)
+	}
+}
+
+// recordReader reads the DB records written in one of the available record formats
+type recordReader interface {
+	// nextRecord returns the next record or nil after the last one
+	nextRecord() (*dbRecord, error)
+}
+
+func newRecordReader(reader io.Reader, format string) (recordReader, error) {
+	switch format {
+	case JSONLRecordFormat:
+		decoder := json.NewDecoder(reader)
+		decoder.DisallowUnknownFields()
+		return &jsonlRecordReader{decoder: decoder}, nil
+	case CSVRecordFormat:
+		return newCSVRecordReader(reader)
+	default:
+		return nil, CheckRecordFormat(format)
+	}
+}
+
+type jsonlRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (rw *jsonlRecordWriter) writeRecord(record *dbRecord) error {
+	return rw.encoder.Encode(record)
+}
+
+func (rw *jsonlRecordWriter) flush() error {
+	return nil
+}
+
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func (rw *csvRecordWriter) writeRecord(record *dbRecord) error {
+	row := []string{record.DB, record.Key, record.KeyEncoding}
+	if record.Value != nil {
+		row = append(row, *record.Value, record.ValueEncoding)
+	}
+
+	return rw.writer.Write(row)
+}
+
+func (rw *csvRecordWriter) flush() error {
+	rw.writer.Flush()
+	return rw.writer.Error()
+}
+
+type jsonlRecordReader struct {
+	decoder    *json.Decoder
+	numRecords int
+}
+
+func (rr *jsonlRecordReader) nextRecord() (*dbRecord, error) {
+	record := &dbRecord{}
+	err := rr.decoder.Decode(record)
+	if err == io.EOF {
+		return nil, nil
+	}
+	rr.numRecords++
+	if err != nil {
+		return nil, fmt.Errorf("%w, record %d: %s", errInvalidRecord, rr.numRecords, err.Error())
+	}
+
+	return record, nil
+}
+
+// csvRecordReader reads the CSV rows after the header row, the columns being matched by the header names. The key
+// and value columns are mandatory
+type csvRecordReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRecordReader(reader io.Reader) (*csvRecordReader, error) {
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing CSV header", errInvalidRecord)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidRecord, err.Error())
+	}
+
+	rr := &csvRecordReader{
+		reader:  csvReader,
+		columns: make(map[string]int),
+	}
+	for index, column := range header {
+		if !isRecordsColumn(column) {
+			return nil, fmt.Errorf("%w: unknown CSV column %q, available columns: %s", errInvalidRecord, column,
+				strings.Join(recordsHeader, ", "))
+		}
+		rr.columns[column] = index
+	}
+	for _, column := range []string{"key", "value"} {
+		_, found := rr.columns[column]
+		if !found {
+			return nil, fmt.Errorf("%w: missing CSV column %q", errInvalidRecord, column)
+		}
+	}
+
+	return rr, nil
+}
+
+func isRecordsColumn(column string) bool {
+	for _, availableColumn := range recordsHeader {
+		if column == availableColumn {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rr *csvRecordReader) nextRecord() (*dbRecord, error) {
+	row, err := rr.reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidRecord, err.Error())
+	}
+
+	value := rr.column(row, "value")
+	return &dbRecord{
+		DB:            rr.column(row, "db"),
+		Key:           rr.column(row, "key"),
+		KeyEncoding:   rr.column(row, "keyEncoding"),
+		Value:         &value,
+		ValueEncoding: rr.column(row, "valueEncoding"),
+	}, nil
+}
+
+func (rr *csvRecordReader) column(row []string, name string) string {
+	index, found := rr.columns[name]
+	if !found {
+		return ""
+	}
+
+	return row[index]
+}
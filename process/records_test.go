@@ -0,0 +1,239 @@
+package process
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckEncoding(t *testing.T) {
+	t.Parallel()
+
+	for _, encoding := range Encodings() {
+		assert.Nil(t, CheckEncoding(encoding))
+	}
+
+	err := CheckEncoding("binary")
+	assert.ErrorIs(t, err, errUnknownEncoding)
+	assert.Contains(t, err.Error(), "hex, base64, utf8, auto")
+}
+
+func TestCheckRecordFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range RecordFormats() {
+		assert.Nil(t, CheckRecordFormat(format))
+	}
+
+	err := CheckRecordFormat("xml")
+	assert.ErrorIs(t, err, errUnknownRecordFormat)
+	assert.Contains(t, err.Error(), "jsonl, csv")
+}
+
+func TestEncodeField(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte{0x00, 0xff, 0x10}
+	testCases := []struct {
+		name             string
+		data             []byte
+		encoding         string
+		expected         string
+		expectedEncoding string
+	}{
+		{name: "hex", data: []byte("key"), encoding: HexEncoding, expected: "6b6579", expectedEncoding: HexEncoding},
+		{name: "base64", data: binary, encoding: Base64Encoding, expected: "AP8Q", expectedEncoding: Base64Encoding},
+		{name: "utf8", data: []byte("clé"), encoding: UTF8Encoding, expected: "clé", expectedEncoding: UTF8Encoding},
+		{name: "auto with text", data: []byte("a key"), encoding: AutoEncoding, expected: "a key", expectedEncoding: UTF8Encoding},
+		{name: "auto with empty data", data: nil, encoding: AutoEncoding, expected: "", expectedEncoding: UTF8Encoding},
+		{name: "auto with binary data", data: binary, encoding: AutoEncoding, expected: "00ff10", expectedEncoding: HexEncoding},
+		{name: "auto with control characters", data: []byte("a\nb"), encoding: AutoEncoding, expected: "610a62", expectedEncoding: HexEncoding},
+	}
+	for _, tc := range testCases {
+		encoded, usedEncoding, err := encodeField(tc.data, tc.encoding)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, encoded, tc.name)
+		assert.Equal(t, tc.expectedEncoding, usedEncoding, tc.name)
+	}
+
+	_, _, err := encodeField(binary, UTF8Encoding)
+	assert.ErrorIs(t, err, errInvalidUTF8Data)
+
+	_, _, err = encodeField(binary, "binary")
+	assert.ErrorIs(t, err, errUnknownEncoding)
+}
+
+func TestRecordWriter(t *testing.T) {
+	t.Parallel()
+
+	writeRecords := func(format string, keysOnly bool) string {
+		buff := &bytes.Buffer{}
+		writer, err := newRecordWriter(buff, format, keysOnly)
+		assert.Nil(t, err)
+
+		for _, kv := range [][2]string{{"k<1>", "v,1"}, {"k2", ""}} {
+			record, errRecord := newDBRecord("A", []byte(kv[0]), []byte(kv[1]), UTF8Encoding, AutoEncoding, keysOnly)
+			assert.Nil(t, errRecord)
+			assert.Nil(t, writer.writeRecord(record))
+		}
+		assert.Nil(t, writer.flush())
+
+		return buff.String()
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		t.Parallel()
+
+		expected := `{"db":"A","key":"k<1>","keyEncoding":"utf8","value":"v,1","valueEncoding":"utf8"}
+{"db":"A","key":"k2","keyEncoding":"utf8","value":"","valueEncoding":"utf8"}
+`
+		assert.Equal(t, expected, writeRecords(JSONLRecordFormat, false))
+	})
+	t.Run("jsonl keys only", func(t *testing.T) {
+		t.Parallel()
+
+		expected := `{"db":"A","key":"k<1>","keyEncoding":"utf8"}
+{"db":"A","key":"k2","keyEncoding":"utf8"}
+`
+		assert.Equal(t, expected, writeRecords(JSONLRecordFormat, true))
+	})
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		expected := "db,key,keyEncoding,value,valueEncoding\nA,k<1>,utf8,\"v,1\",utf8\nA,k2,utf8,,utf8\n"
+		assert.Equal(t, expected, writeRecords(CSVRecordFormat, false))
+	})
+	t.Run("csv keys only", func(t *testing.T) {
+		t.Parallel()
+
+		expected := "db,key,keyEncoding\nA,k<1>,utf8\nA,k2,utf8\n"
+		assert.Equal(t, expected, writeRecords(CSVRecordFormat, true))
+	})
+	t.Run("unknown format should error", func(t *testing.T) {
+		t.Parallel()
+
+		writer, err := newRecordWriter(&bytes.Buffer{}, "xml", false)
+		assert.Nil(t, writer)
+		assert.ErrorIs(t, err, errUnknownRecordFormat)
+	})
+}
+
+func TestDecodeField(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte{0x00, 0xff, 0x10}
+	testCases := []struct {
+		encoded  string
+		encoding string
+		expected []byte
+	}{
+		{encoded: "00ff10", encoding: HexEncoding, expected: binary},
+		{encoded: "AP8Q", encoding: Base64Encoding, expected: binary},
+		{encoded: "clé", encoding: UTF8Encoding, expected: []byte("clé")},
+		{encoded: "", encoding: HexEncoding, expected: []byte{}},
+	}
+	for _, tc := range testCases {
+		decoded, err := decodeField(tc.encoded, tc.encoding)
+		assert.Nil(t, err, tc.encoding)
+		assert.Equal(t, tc.expected, decoded, tc.encoding)
+	}
+
+	_, err := decodeField("zz", HexEncoding)
+	assert.NotNil(t, err)
+
+	_, err = decodeField("6b", AutoEncoding)
+	assert.ErrorIs(t, err, errUnknownEncoding)
+}
+
+func TestRecordReader(t *testing.T) {
+	t.Parallel()
+
+	readRecords := func(data string, format string) ([]*dbRecord, error) {
+		reader, err := newRecordReader(bytes.NewBufferString(data), format)
+		if err != nil {
+			return nil, err
+		}
+
+		records := make([]*dbRecord, 0)
+		for {
+			record, errRead := reader.nextRecord()
+			if errRead != nil {
+				return nil, errRead
+			}
+			if record == nil {
+				return records, nil
+			}
+			records = append(records, record)
+		}
+	}
+	value := func(val string) *string {
+		return &val
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		t.Parallel()
+
+		data := `{"db":"A","key":"6b31","keyEncoding":"hex","value":"v1","valueEncoding":"utf8"}
+
+{"key":"k2","value":""}
+`
+		records, err := readRecords(data, JSONLRecordFormat)
+		assert.Nil(t, err)
+		expected := []*dbRecord{
+			{DB: "A", Key: "6b31", KeyEncoding: HexEncoding, Value: value("v1"), ValueEncoding: UTF8Encoding},
+			{Key: "k2", Value: value("")},
+		}
+		assert.Equal(t, expected, records)
+	})
+	t.Run("jsonl with unknown field should error", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := readRecords(`{"key":"k1","val":"v1"}`, JSONLRecordFormat)
+		assert.Nil(t, records)
+		assert.ErrorIs(t, err, errInvalidRecord)
+		assert.Contains(t, err.Error(), "record 1")
+	})
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		data := "value,key,db\n\"v,1\",k1,A\n,k2,B\n"
+		records, err := readRecords(data, CSVRecordFormat)
+		assert.Nil(t, err)
+		expected := []*dbRecord{
+			{DB: "A", Key: "k1", Value: value("v,1")},
+			{DB: "B", Key: "k2", Value: value("")},
+		}
+		assert.Equal(t, expected, records)
+	})
+	t.Run("csv with unknown column should error", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := readRecords("key,value,other\n", CSVRecordFormat)
+		assert.Nil(t, records)
+		assert.ErrorIs(t, err, errInvalidRecord)
+		assert.Contains(t, err.Error(), "other")
+	})
+	t.Run("csv without value column should error", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := readRecords("db,key,keyEncoding\nA,k1,utf8\n", CSVRecordFormat)
+		assert.Nil(t, records)
+		assert.ErrorIs(t, err, errInvalidRecord)
+		assert.Contains(t, err.Error(), `"value"`)
+	})
+	t.Run("csv without header should error", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := readRecords("", CSVRecordFormat)
+		assert.Nil(t, records)
+		assert.ErrorIs(t, err, errInvalidRecord)
+	})
+	t.Run("csv with wrong number of columns should error", func(t *testing.T) {
+		t.Parallel()
+
+		records, err := readRecords("key,value\nk1\n", CSVRecordFormat)
+		assert.Nil(t, records)
+		assert.ErrorIs(t, err, errInvalidRecord)
+	})
+}
@@ -0,0 +1,37 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// VerificationPassed is the verification status of a DB holding all the source keys
+	VerificationPassed = "passed"
+	// VerificationFailed is the verification status of a DB missing source keys or, if values are checked,
+	// holding different values
+	VerificationFailed = "failed"
+)
+
+// Verify checks that every source key is present in the destination and, if requested, that the values are equal.
+// The keys only found in the destination are ignored. Each DB from the report is marked as passed or failed and
+// an error listing the failed DBs is returned
+func (report *DiffReport) Verify(checkValues bool) error {
+	failedDBs := make([]string, 0)
+	for _, dbDiff := range report.DBs {
+		dbDiff.Verification = VerificationPassed
+		if dbDiff.OnlyInSource > 0 || (checkValues && dbDiff.Different > 0) {
+			dbDiff.Verification = VerificationFailed
+			failedDBs = append(failedDBs, dbDiff.Name)
+		}
+	}
+
+	report.Verification = VerificationPassed
+	if len(failedDBs) == 0 {
+		return nil
+	}
+
+	report.Verification = VerificationFailed
+
+	return fmt.Errorf("%w for %d/%d DBs: %s", errVerificationFailed, len(failedDBs), len(report.DBs), strings.Join(failedDBs, ", "))
+}
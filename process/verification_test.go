@@ -0,0 +1,62 @@
+package process
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffReport_Verify(t *testing.T) {
+	t.Parallel()
+
+	createReport := func() *DiffReport {
+		return &DiffReport{
+			DBs: []*DBDiff{
+				{Name: "A", DiffCounters: DiffCounters{OnlyInDestination: 3}},
+				{Name: "B", DiffCounters: DiffCounters{Different: 2}},
+				{Name: "C", DiffCounters: DiffCounters{OnlyInSource: 1}},
+			},
+		}
+	}
+
+	t.Run("missing source keys should fail", func(t *testing.T) {
+		t.Parallel()
+
+		report := createReport()
+		err := report.Verify(false)
+		assert.ErrorIs(t, err, errVerificationFailed)
+		assert.Contains(t, err.Error(), "for 1/3 DBs: C")
+		assert.Equal(t, VerificationFailed, report.Verification)
+		assert.Equal(t, VerificationPassed, report.DBs[0].Verification)
+		assert.Equal(t, VerificationPassed, report.DBs[1].Verification)
+		assert.Equal(t, VerificationFailed, report.DBs[2].Verification)
+	})
+	t.Run("different values should fail only if the values are checked", func(t *testing.T) {
+		t.Parallel()
+
+		report := createReport()
+		report.DBs = report.DBs[:2]
+		err := report.Verify(false)
+		assert.Nil(t, err)
+		assert.Equal(t, VerificationPassed, report.Verification)
+
+		err = report.Verify(true)
+		assert.ErrorIs(t, err, errVerificationFailed)
+		assert.Contains(t, err.Error(), "for 1/2 DBs: B")
+		assert.Equal(t, VerificationFailed, report.Verification)
+	})
+	t.Run("text report should contain the verification status", func(t *testing.T) {
+		t.Parallel()
+
+		report := createReport()
+		_ = report.Verify(true)
+
+		buff := &bytes.Buffer{}
+		err := WriteDiffReport(buff, report, TextOutputFormat)
+		assert.Nil(t, err)
+		assert.Contains(t, buff.String(), "DB A: verification passed\n")
+		assert.Contains(t, buff.String(), "DB B: verification failed\n")
+		assert.Contains(t, buff.String(), "Verification: failed\n")
+	})
+}
@@ -0,0 +1,60 @@
+package testcommon
+
+// CheckpointHandlerStub -
+type CheckpointHandlerStub struct {
+	IsCompletedCalled   func(name string) bool
+	LastKeyCalled       func(name string) []byte
+	SaveProgressCalled  func(name string, lastKey []byte) error
+	MarkCompletedCalled func(name string) error
+	ClearCalled         func() error
+}
+
+// IsCompleted -
+func (stub *CheckpointHandlerStub) IsCompleted(name string) bool {
+	if stub.IsCompletedCalled != nil {
+		return stub.IsCompletedCalled(name)
+	}
+
+	return false
+}
+
+// LastKey -
+func (stub *CheckpointHandlerStub) LastKey(name string) []byte {
+	if stub.LastKeyCalled != nil {
+		return stub.LastKeyCalled(name)
+	}
+
+	return nil
+}
+
+// SaveProgress -
+func (stub *CheckpointHandlerStub) SaveProgress(name string, lastKey []byte) error {
+	if stub.SaveProgressCalled != nil {
+		return stub.SaveProgressCalled(name, lastKey)
+	}
+
+	return nil
+}
+
+// MarkCompleted -
+func (stub *CheckpointHandlerStub) MarkCompleted(name string) error {
+	if stub.MarkCompletedCalled != nil {
+		return stub.MarkCompletedCalled(name)
+	}
+
+	return nil
+}
+
+// Clear -
+func (stub *CheckpointHandlerStub) Clear() error {
+	if stub.ClearCalled != nil {
+		return stub.ClearCalled()
+	}
+
+	return nil
+}
+
+// IsInterfaceNil -
+func (stub *CheckpointHandlerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
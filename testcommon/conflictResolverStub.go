@@ -0,0 +1,20 @@
+package testcommon
+
+// ConflictResolverStub -
+type ConflictResolverStub struct {
+	ShouldOverwriteCalled func(key []byte, srcVal []byte, destVal []byte) (bool, error)
+}
+
+// ShouldOverwrite -
+func (stub *ConflictResolverStub) ShouldOverwrite(key []byte, srcVal []byte, destVal []byte) (bool, error) {
+	if stub.ShouldOverwriteCalled != nil {
+		return stub.ShouldOverwriteCalled(key, srcVal, destVal)
+	}
+
+	return false, nil
+}
+
+// IsInterfaceNil -
+func (stub *ConflictResolverStub) IsInterfaceNil() bool {
+	return stub == nil
+}
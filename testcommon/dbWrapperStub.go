@@ -1,12 +1,18 @@
 package testcommon
 
+import "iulianpascalau/level-db-copy-go/common"
+
 // DBWrapperStub -
 type DBWrapperStub struct {
-	OpenCalled      func(path string) error
-	RangeKeysCalled func(handler func(key []byte, val []byte) bool)
-	GetCalled       func(key []byte) ([]byte, error)
-	PutCalled       func(key, val []byte) error
-	CloseCalled     func() error
+	OpenCalled         func(path string) error
+	RangeKeysCalled    func(handler func(key []byte, val []byte) bool)
+	NewIteratorCalled  func(startKey []byte, endKey []byte) (common.DBIterator, error)
+	SnapshotInfoCalled func() *common.SnapshotInfo
+	GetCalled          func(key []byte) ([]byte, error)
+	PutCalled          func(key, val []byte) error
+	WriteBatchCalled   func(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error
+	RemoveCalled       func(key []byte) error
+	CloseCalled        func() error
 }
 
 // Open -
@@ -25,6 +31,24 @@ func (stub *DBWrapperStub) RangeKeys(handler func(key []byte, val []byte) bool)
 	}
 }
 
+// NewIterator -
+func (stub *DBWrapperStub) NewIterator(startKey []byte, endKey []byte) (common.DBIterator, error) {
+	if stub.NewIteratorCalled != nil {
+		return stub.NewIteratorCalled(startKey, endKey)
+	}
+
+	return NewInMemoryIterator(nil, nil), nil
+}
+
+// SnapshotInfo -
+func (stub *DBWrapperStub) SnapshotInfo() *common.SnapshotInfo {
+	if stub.SnapshotInfoCalled != nil {
+		return stub.SnapshotInfoCalled()
+	}
+
+	return nil
+}
+
 // Get -
 func (stub *DBWrapperStub) Get(key []byte) ([]byte, error) {
 	if stub.GetCalled != nil {
@@ -43,6 +67,24 @@ func (stub *DBWrapperStub) Put(key, val []byte) error {
 	return nil
 }
 
+// WriteBatch -
+func (stub *DBWrapperStub) WriteBatch(keys [][]byte, values [][]byte, removedKeys [][]byte, sync bool) error {
+	if stub.WriteBatchCalled != nil {
+		return stub.WriteBatchCalled(keys, values, removedKeys, sync)
+	}
+
+	return nil
+}
+
+// Remove -
+func (stub *DBWrapperStub) Remove(key []byte) error {
+	if stub.RemoveCalled != nil {
+		return stub.RemoveCalled(key)
+	}
+
+	return nil
+}
+
 // Close -
 func (stub *DBWrapperStub) Close() error {
 	if stub.CloseCalled != nil {
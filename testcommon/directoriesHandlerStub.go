@@ -2,28 +2,68 @@ package testcommon
 
 // DirectoriesHandlerStub -
 type DirectoriesHandlerStub struct {
-	SourceDirectoriesCalled      func() []string
-	DestinationDirectoriesCalled func() []string
+	SourceParentDirectoriesCalled      func() []string
+	DestinationParentDirectoriesCalled func() []string
+	SourceDirectoriesCalled            func(parentDir string) []string
+	DestinationDirectoriesCalled       func(parentDir string) []string
+	DestinationNameCalled              func(sourceName string) string
+	IsSelectedCalled                   func(name string) bool
+}
+
+// SourceParentDirectories -
+func (stub *DirectoriesHandlerStub) SourceParentDirectories() []string {
+	if stub.SourceParentDirectoriesCalled != nil {
+		return stub.SourceParentDirectoriesCalled()
+	}
+
+	return []string{""}
+}
+
+// DestinationParentDirectories -
+func (stub *DirectoriesHandlerStub) DestinationParentDirectories() []string {
+	if stub.DestinationParentDirectoriesCalled != nil {
+		return stub.DestinationParentDirectoriesCalled()
+	}
+
+	return []string{""}
 }
 
 // SourceDirectories -
-func (stub *DirectoriesHandlerStub) SourceDirectories() []string {
+func (stub *DirectoriesHandlerStub) SourceDirectories(parentDir string) []string {
 	if stub.SourceDirectoriesCalled != nil {
-		return stub.SourceDirectoriesCalled()
+		return stub.SourceDirectoriesCalled(parentDir)
 	}
 
 	return make([]string, 0)
 }
 
 // DestinationDirectories -
-func (stub *DirectoriesHandlerStub) DestinationDirectories() []string {
+func (stub *DirectoriesHandlerStub) DestinationDirectories(parentDir string) []string {
 	if stub.DestinationDirectoriesCalled != nil {
-		return stub.DestinationDirectoriesCalled()
+		return stub.DestinationDirectoriesCalled(parentDir)
 	}
 
 	return make([]string, 0)
 }
 
+// DestinationName -
+func (stub *DirectoriesHandlerStub) DestinationName(sourceName string) string {
+	if stub.DestinationNameCalled != nil {
+		return stub.DestinationNameCalled(sourceName)
+	}
+
+	return sourceName
+}
+
+// IsSelected -
+func (stub *DirectoriesHandlerStub) IsSelected(name string) bool {
+	if stub.IsSelectedCalled != nil {
+		return stub.IsSelectedCalled(name)
+	}
+
+	return true
+}
+
 // IsInterfaceNil -
 func (stub *DirectoriesHandlerStub) IsInterfaceNil() bool {
 	return stub == nil
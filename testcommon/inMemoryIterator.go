@@ -0,0 +1,87 @@
+package testcommon
+
+import (
+	"bytes"
+	"sort"
+)
+
+// InMemoryIterator -
+type InMemoryIterator struct {
+	keys     [][]byte
+	values   [][]byte
+	index    int
+	err      error
+	released bool
+}
+
+// NewInMemoryIterator creates an iterator over the provided sorted keys and their values
+func NewInMemoryIterator(keys [][]byte, values [][]byte) *InMemoryIterator {
+	return &InMemoryIterator{
+		keys:   keys,
+		values: values,
+		index:  -1,
+	}
+}
+
+// NewInMemoryIteratorWithError creates an empty iterator that reports the provided error
+func NewInMemoryIteratorWithError(err error) *InMemoryIterator {
+	iterator := NewInMemoryIterator(nil, nil)
+	iterator.err = err
+
+	return iterator
+}
+
+// Seek -
+func (iterator *InMemoryIterator) Seek(key []byte) bool {
+	iterator.index = sort.Search(len(iterator.keys), func(i int) bool {
+		return bytes.Compare(iterator.keys[i], key) >= 0
+	})
+
+	return iterator.isValid()
+}
+
+// Next -
+func (iterator *InMemoryIterator) Next() bool {
+	if iterator.index < len(iterator.keys) {
+		iterator.index++
+	}
+
+	return iterator.isValid()
+}
+
+func (iterator *InMemoryIterator) isValid() bool {
+	return !iterator.released && iterator.index >= 0 && iterator.index < len(iterator.keys)
+}
+
+// Key -
+func (iterator *InMemoryIterator) Key() []byte {
+	if !iterator.isValid() {
+		return nil
+	}
+
+	return iterator.keys[iterator.index]
+}
+
+// Value -
+func (iterator *InMemoryIterator) Value() []byte {
+	if !iterator.isValid() {
+		return nil
+	}
+
+	return iterator.values[iterator.index]
+}
+
+// Error -
+func (iterator *InMemoryIterator) Error() error {
+	return iterator.err
+}
+
+// Release -
+func (iterator *InMemoryIterator) Release() {
+	iterator.released = true
+}
+
+// IsReleased -
+func (iterator *InMemoryIterator) IsReleased() bool {
+	return iterator.released
+}
@@ -0,0 +1,20 @@
+package testcommon
+
+// LockCheckerStub -
+type LockCheckerStub struct {
+	IsLockedCalled func(path string) (bool, error)
+}
+
+// IsLocked -
+func (stub *LockCheckerStub) IsLocked(path string) (bool, error) {
+	if stub.IsLockedCalled != nil {
+		return stub.IsLockedCalled(path)
+	}
+
+	return false, nil
+}
+
+// IsInterfaceNil -
+func (stub *LockCheckerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
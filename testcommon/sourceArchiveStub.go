@@ -0,0 +1,30 @@
+package testcommon
+
+// SourceArchiveStub -
+type SourceArchiveStub struct {
+	PathCalled         func() string
+	SectionPathsCalled func() []string
+}
+
+// Path -
+func (stub *SourceArchiveStub) Path() string {
+	if stub.PathCalled != nil {
+		return stub.PathCalled()
+	}
+
+	return ""
+}
+
+// SectionPaths -
+func (stub *SourceArchiveStub) SectionPaths() []string {
+	if stub.SectionPathsCalled != nil {
+		return stub.SectionPathsCalled()
+	}
+
+	return make([]string, 0)
+}
+
+// IsInterfaceNil -
+func (stub *SourceArchiveStub) IsInterfaceNil() bool {
+	return stub == nil
+}